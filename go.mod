@@ -3,6 +3,11 @@ module GoBlogAggregator
 go 1.22.4
 
 require (
-	github.com/google/uuid v1.6.0 // indirect
-	github.com/lib/pq v1.10.9 // indirect
+	github.com/andybalholm/brotli v1.1.0
+	github.com/google/uuid v1.6.0
+	github.com/lib/pq v1.10.9
+	golang.org/x/net v0.28.0
+	golang.org/x/time v0.5.0
 )
+
+require golang.org/x/text v0.17.0 // indirect
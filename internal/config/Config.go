@@ -9,6 +9,31 @@ import (
 type Config struct {
 	DbURL           string `json:"db_url"`
 	CurrentUserName string `json:"current_user_name"`
+	// consecutive failures after which a feed is disabled, 0 uses the default
+	MaxFeedFailures int `json:"max_feed_failures,omitempty"`
+	// fetch limits, durations use time.ParseDuration syntax, empty or 0 uses the default
+	FetchConnectTimeout string `json:"fetch_connect_timeout,omitempty"`
+	FetchTimeout        string `json:"fetch_timeout,omitempty"`
+	MaxFeedSize         int64  `json:"max_feed_size,omitempty"`
+	// bounds of the refresh interval computed for every feed, empty uses the default
+	MinRefreshInterval string `json:"min_refresh_interval,omitempty"`
+	MaxRefreshInterval string `json:"max_refresh_interval,omitempty"`
+	// fetch rate limits keyed by domain, e.g. "substack.com" also covers its subdomains
+	HostRateLimits map[string]HostRateLimit `json:"host_rate_limits,omitempty"`
+	// rate limit of hosts without an entry in HostRateLimits, unset uses the default
+	DefaultHostRateLimit *HostRateLimit `json:"default_host_rate_limit,omitempty"`
+	// WebSub push subscriptions are used when both are set: agg listens on WebsubListenAddr,
+	// e.g. ":8080", and hubs reach that listener at WebsubCallbackURL, e.g. "https://example.com/websub"
+	WebsubCallbackURL string `json:"websub_callback_url,omitempty"`
+	WebsubListenAddr  string `json:"websub_listen_addr,omitempty"`
+	// directory the download command saves enclosures to, empty uses ~/gator-downloads
+	DownloadDir string `json:"download_dir,omitempty"`
+}
+
+// HostRateLimit is a token bucket, refilled at RequestsPerMinute and holding up to Burst requests
+type HostRateLimit struct {
+	RequestsPerMinute float64 `json:"requests_per_minute"`
+	Burst             int     `json:"burst"`
 }
 
 func (c *Config) SetUser(userName string) error {
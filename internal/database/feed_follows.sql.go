@@ -76,7 +76,8 @@ DELETE FROM feed_follows
 USING feeds
 WHERE $1 = feed_follows.user_id
 AND feed_follows.feed_id = feeds.id
-AND $2 = feeds.url
+AND ($2 = feeds.url
+OR feeds.id IN (SELECT feed_aliases.feed_id FROM feed_aliases WHERE feed_aliases.url = $2))
 `
 
 type DeleteFeedFollowsByUserParams struct {
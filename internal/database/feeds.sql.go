@@ -13,6 +13,79 @@ import (
 	"github.com/google/uuid"
 )
 
+const claimFeedsToFetch = `-- name: ClaimFeedsToFetch :many
+UPDATE feeds
+SET last_fetched_at = $1,
+    updated_at = $1,
+    lease_owner = $2,
+    lease_expires_at = $3
+WHERE id IN (
+    SELECT id FROM feeds
+    WHERE (feeds.lease_expires_at IS NULL OR feeds.lease_expires_at < $1)
+    AND (feeds.next_fetch_at IS NULL OR feeds.next_fetch_at <= $1)
+    AND feeds.disabled_at IS NULL
+    ORDER BY feeds.last_fetched_at ASC NULLS FIRST
+    LIMIT $4
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, lease_owner, lease_expires_at, consecutive_failures, last_error, next_fetch_at, last_success_at, last_status_code, disabled_at, last_error_kind, refresh_interval_seconds
+`
+
+type ClaimFeedsToFetchParams struct {
+	Time           sql.NullTime
+	LeaseOwner     uuid.NullUUID
+	LeaseExpiresAt sql.NullTime
+	MaxFeeds       int32
+}
+
+func (q *Queries) ClaimFeedsToFetch(ctx context.Context, arg ClaimFeedsToFetchParams) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, claimFeedsToFetch,
+		arg.Time,
+		arg.LeaseOwner,
+		arg.LeaseExpiresAt,
+		arg.MaxFeeds,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.LeaseOwner,
+			&i.LeaseExpiresAt,
+			&i.ConsecutiveFailures,
+			&i.LastError,
+			&i.NextFetchAt,
+			&i.LastSuccessAt,
+			&i.LastStatusCode,
+			&i.DisabledAt,
+			&i.LastErrorKind,
+			&i.RefreshIntervalSeconds,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createFeed = `-- name: CreateFeed :one
 INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
 VALUES (
@@ -23,7 +96,7 @@ VALUES (
     $5,
     $6
 )
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, lease_owner, lease_expires_at, consecutive_failures, last_error, next_fetch_at, last_success_at, last_status_code, disabled_at, last_error_kind, refresh_interval_seconds
 `
 
 type CreateFeedParams struct {
@@ -53,12 +126,75 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.LeaseOwner,
+		&i.LeaseExpiresAt,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.NextFetchAt,
+		&i.LastSuccessAt,
+		&i.LastStatusCode,
+		&i.DisabledAt,
+		&i.LastErrorKind,
+		&i.RefreshIntervalSeconds,
+	)
+	return i, err
+}
+
+const disableFeed = `-- name: DisableFeed :exec
+UPDATE feeds SET disabled_at = $1 WHERE id = $2
+`
+
+type DisableFeedParams struct {
+	DisabledAt sql.NullTime
+	ID         uuid.UUID
+}
+
+func (q *Queries) DisableFeed(ctx context.Context, arg DisableFeedParams) error {
+	_, err := q.db.ExecContext(ctx, disableFeed, arg.DisabledAt, arg.ID)
+	return err
+}
+
+const enableFeed = `-- name: EnableFeed :one
+UPDATE feeds
+SET disabled_at = NULL,
+    consecutive_failures = 0,
+    next_fetch_at = NULL
+WHERE url = $1
+OR id IN (SELECT feed_aliases.feed_id FROM feed_aliases WHERE feed_aliases.url = $1)
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, lease_owner, lease_expires_at, consecutive_failures, last_error, next_fetch_at, last_success_at, last_status_code, disabled_at, last_error_kind, refresh_interval_seconds
+`
+
+func (q *Queries) EnableFeed(ctx context.Context, url sql.NullString) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, enableFeed, url)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.LeaseOwner,
+		&i.LeaseExpiresAt,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.NextFetchAt,
+		&i.LastSuccessAt,
+		&i.LastStatusCode,
+		&i.DisabledAt,
+		&i.LastErrorKind,
+		&i.RefreshIntervalSeconds,
 	)
 	return i, err
 }
 
 const getFeedByID = `-- name: GetFeedByID :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, lease_owner, lease_expires_at, consecutive_failures, last_error, next_fetch_at, last_success_at, last_status_code, disabled_at, last_error_kind, refresh_interval_seconds FROM feeds
 WHERE feeds.id = $1
 `
 
@@ -73,13 +209,26 @@ func (q *Queries) GetFeedByID(ctx context.Context, id uuid.UUID) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.LeaseOwner,
+		&i.LeaseExpiresAt,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.NextFetchAt,
+		&i.LastSuccessAt,
+		&i.LastStatusCode,
+		&i.DisabledAt,
+		&i.LastErrorKind,
+		&i.RefreshIntervalSeconds,
 	)
 	return i, err
 }
 
 const getFeedByURL = `-- name: GetFeedByURL :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, lease_owner, lease_expires_at, consecutive_failures, last_error, next_fetch_at, last_success_at, last_status_code, disabled_at, last_error_kind, refresh_interval_seconds FROM feeds
 WHERE feeds.url = $1
+OR feeds.id IN (SELECT feed_aliases.feed_id FROM feed_aliases WHERE feed_aliases.url = $1)
 `
 
 func (q *Queries) GetFeedByURL(ctx context.Context, url sql.NullString) (Feed, error) {
@@ -93,12 +242,24 @@ func (q *Queries) GetFeedByURL(ctx context.Context, url sql.NullString) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.LeaseOwner,
+		&i.LeaseExpiresAt,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.NextFetchAt,
+		&i.LastSuccessAt,
+		&i.LastStatusCode,
+		&i.DisabledAt,
+		&i.LastErrorKind,
+		&i.RefreshIntervalSeconds,
 	)
 	return i, err
 }
 
 const getFeeds = `-- name: GetFeeds :many
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, lease_owner, lease_expires_at, consecutive_failures, last_error, next_fetch_at, last_success_at, last_status_code, disabled_at, last_error_kind, refresh_interval_seconds FROM feeds
 `
 
 func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
@@ -118,6 +279,18 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
 			&i.Url,
 			&i.UserID,
 			&i.LastFetchedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.LeaseOwner,
+			&i.LeaseExpiresAt,
+			&i.ConsecutiveFailures,
+			&i.LastError,
+			&i.NextFetchAt,
+			&i.LastSuccessAt,
+			&i.LastStatusCode,
+			&i.DisabledAt,
+			&i.LastErrorKind,
+			&i.RefreshIntervalSeconds,
 		); err != nil {
 			return nil, err
 		}
@@ -132,36 +305,134 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
 	return items, nil
 }
 
-const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
-ORDER BY last_fetched_at ASC NULLS FIRST
+const postponeFeedFetch = `-- name: PostponeFeedFetch :exec
+UPDATE feeds SET next_fetch_at = $1 WHERE id = $2
 `
 
-func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
-	row := q.db.QueryRowContext(ctx, getNextFeedToFetch)
-	var i Feed
-	err := row.Scan(
-		&i.ID,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.Name,
-		&i.Url,
-		&i.UserID,
-		&i.LastFetchedAt,
+type PostponeFeedFetchParams struct {
+	NextFetchAt sql.NullTime
+	ID          uuid.UUID
+}
+
+func (q *Queries) PostponeFeedFetch(ctx context.Context, arg PostponeFeedFetchParams) error {
+	_, err := q.db.ExecContext(ctx, postponeFeedFetch, arg.NextFetchAt, arg.ID)
+	return err
+}
+
+const recordFeedFailure = `-- name: RecordFeedFailure :exec
+UPDATE feeds
+SET consecutive_failures = consecutive_failures + 1,
+    last_error = $1,
+    last_error_kind = $2,
+    last_status_code = $3,
+    next_fetch_at = $4
+WHERE id = $5
+`
+
+type RecordFeedFailureParams struct {
+	LastError      sql.NullString
+	LastErrorKind  sql.NullString
+	LastStatusCode sql.NullInt32
+	NextFetchAt    sql.NullTime
+	ID             uuid.UUID
+}
+
+func (q *Queries) RecordFeedFailure(ctx context.Context, arg RecordFeedFailureParams) error {
+	_, err := q.db.ExecContext(ctx, recordFeedFailure,
+		arg.LastError,
+		arg.LastErrorKind,
+		arg.LastStatusCode,
+		arg.NextFetchAt,
+		arg.ID,
 	)
-	return i, err
+	return err
+}
+
+const recordFeedSuccess = `-- name: RecordFeedSuccess :exec
+UPDATE feeds
+SET consecutive_failures = 0,
+    last_error = NULL,
+    last_error_kind = NULL,
+    last_status_code = $1,
+    last_success_at = $2,
+    next_fetch_at = $3,
+    refresh_interval_seconds = $4
+WHERE id = $5
+`
+
+type RecordFeedSuccessParams struct {
+	LastStatusCode         sql.NullInt32
+	LastSuccessAt          sql.NullTime
+	NextFetchAt            sql.NullTime
+	RefreshIntervalSeconds sql.NullInt32
+	ID                     uuid.UUID
 }
 
-const markFeedFetched = `-- name: MarkFeedFetched :exec
-UPDATE feeds SET last_fetched_at = $1, updated_at = $1 WHERE id = $2
+func (q *Queries) RecordFeedSuccess(ctx context.Context, arg RecordFeedSuccessParams) error {
+	_, err := q.db.ExecContext(ctx, recordFeedSuccess,
+		arg.LastStatusCode,
+		arg.LastSuccessAt,
+		arg.NextFetchAt,
+		arg.RefreshIntervalSeconds,
+		arg.ID,
+	)
+	return err
+}
+
+const releaseFeedLease = `-- name: ReleaseFeedLease :exec
+UPDATE feeds SET lease_owner = NULL, lease_expires_at = NULL
+WHERE id = $1 AND lease_owner = $2
+`
+
+type ReleaseFeedLeaseParams struct {
+	ID         uuid.UUID
+	LeaseOwner uuid.NullUUID
+}
+
+func (q *Queries) ReleaseFeedLease(ctx context.Context, arg ReleaseFeedLeaseParams) error {
+	_, err := q.db.ExecContext(ctx, releaseFeedLease, arg.ID, arg.LeaseOwner)
+	return err
+}
+
+const updateFeedCacheHeaders = `-- name: UpdateFeedCacheHeaders :exec
+UPDATE feeds SET etag = $1, last_modified = $2 WHERE id = $3
 `
 
-type MarkFeedFetchedParams struct {
-	Time sql.NullTime
-	ID   uuid.UUID
+type UpdateFeedCacheHeadersParams struct {
+	Etag         sql.NullString
+	LastModified sql.NullString
+	ID           uuid.UUID
 }
 
-func (q *Queries) MarkFeedFetched(ctx context.Context, arg MarkFeedFetchedParams) error {
-	_, err := q.db.ExecContext(ctx, markFeedFetched, arg.Time, arg.ID)
+func (q *Queries) UpdateFeedCacheHeaders(ctx context.Context, arg UpdateFeedCacheHeadersParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedCacheHeaders, arg.Etag, arg.LastModified, arg.ID)
+	return err
+}
+
+const updateFeedURL = `-- name: UpdateFeedURL :exec
+WITH inserted_alias AS (
+    INSERT INTO feed_aliases (id, created_at, feed_id, url)
+    SELECT $1::uuid, $2::timestamp, feeds.id, feeds.url
+    FROM feeds
+    WHERE feeds.id = $3 AND feeds.url IS NOT NULL
+    ON CONFLICT (url) DO NOTHING
+)
+UPDATE feeds SET url = $4, updated_at = $2::timestamp WHERE feeds.id = $3
+`
+
+type UpdateFeedURLParams struct {
+	AliasID   uuid.UUID
+	UpdatedAt time.Time
+	ID        uuid.UUID
+	Url       sql.NullString
+}
+
+func (q *Queries) UpdateFeedURL(ctx context.Context, arg UpdateFeedURLParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedURL,
+		arg.AliasID,
+		arg.UpdatedAt,
+		arg.ID,
+		arg.Url,
+	)
 	return err
 }
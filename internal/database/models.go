@@ -12,13 +12,32 @@ import (
 )
 
 type Feed struct {
-	ID            uuid.UUID
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	Name          sql.NullString
-	Url           sql.NullString
-	UserID        uuid.NullUUID
-	LastFetchedAt sql.NullTime
+	ID                     uuid.UUID
+	CreatedAt              time.Time
+	UpdatedAt              time.Time
+	Name                   sql.NullString
+	Url                    sql.NullString
+	UserID                 uuid.NullUUID
+	LastFetchedAt          sql.NullTime
+	Etag                   sql.NullString
+	LastModified           sql.NullString
+	LeaseOwner             uuid.NullUUID
+	LeaseExpiresAt         sql.NullTime
+	ConsecutiveFailures    int32
+	LastError              sql.NullString
+	NextFetchAt            sql.NullTime
+	LastSuccessAt          sql.NullTime
+	LastStatusCode         sql.NullInt32
+	DisabledAt             sql.NullTime
+	LastErrorKind          sql.NullString
+	RefreshIntervalSeconds sql.NullInt32
+}
+
+type FeedAlias struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	FeedID    uuid.UUID
+	Url       string
 }
 
 type FeedFollow struct {
@@ -30,14 +49,33 @@ type FeedFollow struct {
 }
 
 type Post struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Title       sql.NullString
-	Url         sql.NullString
-	Description sql.NullString
-	PublishedAt sql.NullTime
-	FeedID      uuid.NullUUID
+	ID            uuid.UUID
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	Title         sql.NullString
+	Url           sql.NullString
+	Description   sql.NullString
+	PublishedAt   sql.NullTime
+	FeedID        uuid.NullUUID
+	Guid          string
+	ContentHash   string
+	RevisionCount int32
+	Content       sql.NullString
+	PlainText     sql.NullString
+}
+
+type PostEnclosure struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	PostID          uuid.UUID
+	Url             string
+	Length          sql.NullInt64
+	MimeType        sql.NullString
+	DurationSeconds sql.NullInt32
+	Season          sql.NullInt32
+	Episode         sql.NullInt32
+	ThumbnailUrl    sql.NullString
 }
 
 type User struct {
@@ -46,3 +84,16 @@ type User struct {
 	UpdatedAt time.Time
 	Name      string
 }
+
+type WebsubSubscription struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	FeedID      uuid.UUID
+	HubUrl      string
+	TopicUrl    string
+	Secret      string
+	RequestedAt time.Time
+	VerifiedAt  sql.NullTime
+	ExpiresAt   sql.NullTime
+}
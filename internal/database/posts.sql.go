@@ -13,43 +13,79 @@ import (
 	"github.com/google/uuid"
 )
 
-const createPosts = `-- name: CreatePosts :one
-INSERT INTO posts(id, created_at, updated_at, title, url, description, published_at, feed_id)
-VALUES(
-    $1,
-    $2,
-    $3,
-    $4,
-    $5,
-    $6,
-    $7,
-    $8
-)
-RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id
+const adoptLegacyPostGUID = `-- name: AdoptLegacyPostGUID :exec
+UPDATE posts
+SET guid = $1
+WHERE feed_id = $2
+    AND url = $3
+    AND guid = url
+    AND guid <> $1
+    AND NOT EXISTS (
+        SELECT 1 FROM posts AS existing
+        WHERE existing.feed_id = $2 AND existing.guid = $1
+    )
 `
 
-type CreatePostsParams struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Title       sql.NullString
-	Url         sql.NullString
-	Description sql.NullString
-	PublishedAt sql.NullTime
-	FeedID      uuid.NullUUID
+type AdoptLegacyPostGUIDParams struct {
+	Guid   string
+	FeedID uuid.NullUUID
+	Url    sql.NullString
 }
 
-func (q *Queries) CreatePosts(ctx context.Context, arg CreatePostsParams) (Post, error) {
-	row := q.db.QueryRowContext(ctx, createPosts,
-		arg.ID,
-		arg.CreatedAt,
-		arg.UpdatedAt,
-		arg.Title,
-		arg.Url,
-		arg.Description,
-		arg.PublishedAt,
-		arg.FeedID,
-	)
+func (q *Queries) AdoptLegacyPostGUID(ctx context.Context, arg AdoptLegacyPostGUIDParams) error {
+	_, err := q.db.ExecContext(ctx, adoptLegacyPostGUID, arg.Guid, arg.FeedID, arg.Url)
+	return err
+}
+
+const getFeedPostStats = `-- name: GetFeedPostStats :many
+SELECT feed_id, COUNT(*) AS post_count, MIN(published_at)::timestamp AS oldest_post_at, MAX(published_at)::timestamp AS newest_post_at
+FROM posts
+WHERE published_at IS NOT NULL
+GROUP BY feed_id
+`
+
+type GetFeedPostStatsRow struct {
+	FeedID       uuid.NullUUID
+	PostCount    int64
+	OldestPostAt time.Time
+	NewestPostAt time.Time
+}
+
+func (q *Queries) GetFeedPostStats(ctx context.Context) ([]GetFeedPostStatsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedPostStats)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFeedPostStatsRow
+	for rows.Next() {
+		var i GetFeedPostStatsRow
+		if err := rows.Scan(
+			&i.FeedID,
+			&i.PostCount,
+			&i.OldestPostAt,
+			&i.NewestPostAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPost = `-- name: GetPost :one
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, guid, content_hash, revision_count, content, plain_text FROM posts
+WHERE id = $1
+`
+
+func (q *Queries) GetPost(ctx context.Context, id uuid.UUID) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getPost, id)
 	var i Post
 	err := row.Scan(
 		&i.ID,
@@ -60,12 +96,34 @@ func (q *Queries) CreatePosts(ctx context.Context, arg CreatePostsParams) (Post,
 		&i.Description,
 		&i.PublishedAt,
 		&i.FeedID,
+		&i.Guid,
+		&i.ContentHash,
+		&i.RevisionCount,
+		&i.Content,
+		&i.PlainText,
 	)
 	return i, err
 }
 
+const getPostIDByGUID = `-- name: GetPostIDByGUID :one
+SELECT id FROM posts
+WHERE feed_id = $1 AND guid = $2
+`
+
+type GetPostIDByGUIDParams struct {
+	FeedID uuid.NullUUID
+	Guid   string
+}
+
+func (q *Queries) GetPostIDByGUID(ctx context.Context, arg GetPostIDByGUIDParams) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, getPostIDByGUID, arg.FeedID, arg.Guid)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
 const getPostsForUser = `-- name: GetPostsForUser :many
-SELECT posts.title, posts.description, posts.published_at, posts.url, feeds.name FROM posts
+SELECT posts.id, posts.title, posts.description, posts.published_at, posts.url, posts.revision_count, posts.content IS NOT NULL AS has_content, feeds.name FROM posts
 INNER JOIN feeds
 ON feeds.id = feed_id 
 WHERE feeds.user_id = $1
@@ -79,11 +137,14 @@ type GetPostsForUserParams struct {
 }
 
 type GetPostsForUserRow struct {
-	Title       sql.NullString
-	Description sql.NullString
-	PublishedAt sql.NullTime
-	Url         sql.NullString
-	Name        sql.NullString
+	ID            uuid.UUID
+	Title         sql.NullString
+	Description   sql.NullString
+	PublishedAt   sql.NullTime
+	Url           sql.NullString
+	RevisionCount int32
+	HasContent    bool
+	Name          sql.NullString
 }
 
 func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]GetPostsForUserRow, error) {
@@ -96,10 +157,13 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 	for rows.Next() {
 		var i GetPostsForUserRow
 		if err := rows.Scan(
+			&i.ID,
 			&i.Title,
 			&i.Description,
 			&i.PublishedAt,
 			&i.Url,
+			&i.RevisionCount,
+			&i.HasContent,
 			&i.Name,
 		); err != nil {
 			return nil, err
@@ -114,3 +178,84 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 	}
 	return items, nil
 }
+
+const upsertPost = `-- name: UpsertPost :one
+INSERT INTO posts(id, created_at, updated_at, title, url, description, published_at, feed_id, guid, content_hash, content, plain_text)
+VALUES(
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    $11,
+    $12
+)
+ON CONFLICT (feed_id, guid) DO UPDATE
+SET title = EXCLUDED.title,
+    url = EXCLUDED.url,
+    description = EXCLUDED.description,
+    content = EXCLUDED.content,
+    content_hash = EXCLUDED.content_hash,
+    updated_at = EXCLUDED.updated_at,
+    plain_text = EXCLUDED.plain_text,
+    revision_count = posts.revision_count + CASE
+        WHEN posts.plain_text IS NULL THEN 0
+        ELSE 1
+    END
+WHERE posts.content_hash <> EXCLUDED.content_hash
+RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id, guid, content_hash, revision_count, content, plain_text
+`
+
+type UpsertPostParams struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       sql.NullString
+	Url         sql.NullString
+	Description sql.NullString
+	PublishedAt sql.NullTime
+	FeedID      uuid.NullUUID
+	Guid        string
+	ContentHash string
+	Content     sql.NullString
+	PlainText   sql.NullString
+}
+
+func (q *Queries) UpsertPost(ctx context.Context, arg UpsertPostParams) (Post, error) {
+	row := q.db.QueryRowContext(ctx, upsertPost,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.Title,
+		arg.Url,
+		arg.Description,
+		arg.PublishedAt,
+		arg.FeedID,
+		arg.Guid,
+		arg.ContentHash,
+		arg.Content,
+		arg.PlainText,
+	)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.Guid,
+		&i.ContentHash,
+		&i.RevisionCount,
+		&i.Content,
+		&i.PlainText,
+	)
+	return i, err
+}
@@ -0,0 +1,157 @@
+package fetcher
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mime"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// paths tried on the site when a page links no feed
+var commonFeedPaths = []string{"/feed", "/rss.xml", "/atom.xml", "/feed.xml", "/index.xml"}
+
+// media types of the feeds a page can link with rel="alternate"
+var feedLinkTypes = map[string]bool{
+	"application/rss+xml":   true,
+	"application/atom+xml":  true,
+	"application/rdf+xml":   true,
+	"application/feed+json": true,
+}
+
+// Candidate is a feed found by Discover
+type Candidate struct {
+	URL   string
+	Title string
+	// parser format name when the feed was fetched, the media type of the link otherwise
+	Type string
+}
+
+// Discover returns the feeds a URL leads to: the URL itself when it is a feed, else the feeds
+// the HTML page links with rel="alternate", else the feeds found at common paths of the site
+func (c *Client) Discover(ctx context.Context, pageURL string) ([]Candidate, error) {
+	res, body, err := c.get(ctx, pageURL, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	contentType := res.Header.Get("Content-Type")
+	feed, err := ParseFeed(res.Request.URL.String(), contentType, body)
+	if err == nil {
+		return []Candidate{{URL: pageURL, Title: feed.Title, Type: feed.Format}}, nil
+	}
+	if !isHTML(contentType) && !looksLikeHTML(body) {
+		return nil, err
+	}
+
+	// links are relative to the URL the page was finally served from
+	candidates := htmlFeedLinks(res.Request.URL, body)
+	if len(candidates) > 0 {
+		return candidates, nil
+	}
+	seen := map[string]bool{}
+	for _, path := range commonFeedPaths {
+		candidateURL := res.Request.URL.ResolveReference(&url.URL{Path: path}).String()
+		res, body, err := c.get(ctx, candidateURL, nil, nil)
+		if err != nil {
+			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+				return candidates, err
+			}
+			continue
+		}
+		feed, err := ParseFeed(res.Request.URL.String(), res.Header.Get("Content-Type"), body)
+		if err != nil {
+			continue
+		}
+		// several paths often redirect to the same feed
+		finalURL := res.Request.URL.String()
+		if seen[finalURL] {
+			continue
+		}
+		seen[finalURL] = true
+		candidates = append(candidates, Candidate{URL: candidateURL, Title: feed.Title, Type: feed.Format})
+	}
+	return candidates, nil
+}
+
+// reports whether a body without an HTML Content-Type still is an HTML page
+func looksLikeHTML(body []byte) bool {
+	start := bytes.ToLower(bytes.TrimSpace(body))
+	if len(start) > 512 {
+		start = start[:512]
+	}
+	return bytes.HasPrefix(start, []byte("<!doctype html")) || bytes.Contains(start, []byte("<html"))
+}
+
+// returns the feeds an HTML page links with <link rel="alternate" type="...">,
+// resolved against the page URL or its <base href>
+func htmlFeedLinks(pageURL *url.URL, body []byte) []Candidate {
+	base := pageURL
+	candidates := []Candidate{}
+	seen := map[string]bool{}
+	tokenizer := html.NewTokenizer(bytes.NewReader(body))
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			return candidates
+		}
+		if tokenType != html.StartTagToken && tokenType != html.SelfClosingTagToken {
+			continue
+		}
+		token := tokenizer.Token()
+		switch token.Data {
+		case "base":
+			href, err := url.Parse(strings.TrimSpace(htmlAttr(token, "href")))
+			if err == nil && htmlAttr(token, "href") != "" {
+				base = pageURL.ResolveReference(href)
+			}
+		case "link":
+			if !hasRel(htmlAttr(token, "rel"), "alternate") {
+				continue
+			}
+			mediaType, _, err := mime.ParseMediaType(htmlAttr(token, "type"))
+			if err != nil || !feedLinkTypes[mediaType] {
+				continue
+			}
+			href, err := url.Parse(strings.TrimSpace(htmlAttr(token, "href")))
+			if err != nil || htmlAttr(token, "href") == "" {
+				continue
+			}
+			feedURL := base.ResolveReference(href).String()
+			if seen[feedURL] {
+				continue
+			}
+			seen[feedURL] = true
+			candidates = append(candidates, Candidate{
+				URL:   feedURL,
+				Title: strings.TrimSpace(htmlAttr(token, "title")),
+				Type:  mediaType,
+			})
+		case "body":
+			// feed links belong in the head
+			return candidates
+		}
+	}
+}
+
+// returns the value of an attribute of an HTML tag, empty when absent
+func htmlAttr(token html.Token, name string) string {
+	for _, attr := range token.Attr {
+		if attr.Key == name {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// reports whether a space separated rel attribute contains the link type
+func hasRel(rel string, linkType string) bool {
+	for _, value := range strings.Fields(rel) {
+		if strings.EqualFold(value, linkType) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Download saves the file at rawURL to dest, resuming the dest+".part" file an interrupted
+// download left behind, returns the size of the saved file
+// the fetch timeout and body size limit do not apply, enclosures can be large
+func (c *Client) Download(ctx context.Context, rawURL string, dest string) (int64, error) {
+	partPath := dest + ".part"
+	offset := int64(0)
+	info, err := os.Stat(partPath)
+	if err == nil {
+		offset = info.Size()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	err = c.limiter.Wait(ctx, rawURL)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", "Gator")
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	client := &http.Client{Transport: c.transport}
+	res, err := client.Do(req)
+	if err != nil {
+		return 0, &networkError{Err: err}
+	}
+	defer res.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch {
+	case res.StatusCode == http.StatusPartialContent:
+		start, _, _ := parseContentRange(res.Header.Get("Content-Range"))
+		if start != offset {
+			return 0, fmt.Errorf("asked to resume at byte %d, got Content-Range %q", offset, res.Header.Get("Content-Range"))
+		}
+		flags |= os.O_APPEND
+	case res.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// nothing is left past the end of a complete partial file
+		_, _, total := parseContentRange(res.Header.Get("Content-Range"))
+		if total != offset {
+			return 0, fmt.Errorf("cannot resume %s, remove it to download again", partPath)
+		}
+		return offset, os.Rename(partPath, dest)
+	case res.StatusCode >= 200 && res.StatusCode <= 299:
+		// the server ignored the range, start over
+		offset = 0
+		flags |= os.O_TRUNC
+	default:
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return 0, &HTTPStatusError{
+			StatusCode: res.StatusCode,
+			Status:     res.Status,
+			Snippet:    snippet(body),
+			RetryAfter: retryAfter(res, time.Now()),
+		}
+	}
+
+	file, err := os.OpenFile(partPath, flags, 0644)
+	if err != nil {
+		return 0, err
+	}
+	written, err := io.Copy(file, res.Body)
+	closeErr := file.Close()
+	if err != nil {
+		// the partial file is kept, the next download resumes it
+		return offset + written, &responseError{StatusCode: res.StatusCode, Err: err}
+	}
+	if closeErr != nil {
+		return offset + written, closeErr
+	}
+	if res.ContentLength >= 0 && written != res.ContentLength {
+		return offset + written, &responseError{
+			StatusCode: res.StatusCode,
+			Err:        fmt.Errorf("download ended after %d of %d bytes", written, res.ContentLength),
+		}
+	}
+	return offset + written, os.Rename(partPath, dest)
+}
+
+// parses a Content-Range header, "bytes start-end/total" or "bytes */total", -1 for missing values
+func parseContentRange(header string) (start int64, end int64, total int64) {
+	start, end, total = -1, -1, -1
+	unit, value, found := strings.Cut(strings.TrimSpace(header), " ")
+	if !found || unit != "bytes" {
+		return start, end, total
+	}
+	byteRange, size, found := strings.Cut(value, "/")
+	if !found {
+		return start, end, total
+	}
+	if parsed, err := strconv.ParseInt(size, 10, 64); err == nil {
+		total = parsed
+	}
+	first, last, found := strings.Cut(byteRange, "-")
+	if found {
+		if parsed, err := strconv.ParseInt(first, 10, 64); err == nil {
+			start = parsed
+		}
+		if parsed, err := strconv.ParseInt(last, 10, 64); err == nil {
+			end = parsed
+		}
+	}
+	return start, end, total
+}
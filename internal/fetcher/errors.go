@@ -0,0 +1,169 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// kinds of fetch errors stored on a feed, so the health report can tell them apart
+const (
+	KindNetwork    = "network"
+	KindTimeout    = "timeout"
+	KindHTTPStatus = "http_status"
+	KindNotAFeed   = "not_a_feed"
+	KindTooLarge   = "too_large"
+	KindOther      = "other"
+)
+
+// HTTPStatusError is returned when the server answers with a status other than 2xx or 304
+type HTTPStatusError struct {
+	StatusCode int
+	Status     string
+	// start of the response body, usually an error page
+	Snippet string
+	// Retry-After of a 429 or 503 response, 0 when absent
+	RetryAfter time.Duration
+}
+
+func (e *HTTPStatusError) Error() string {
+	if e.Snippet == "" {
+		return fmt.Sprintf("unexpected HTTP status %s", e.Status)
+	}
+	return fmt.Sprintf("unexpected HTTP status %s: %q", e.Status, e.Snippet)
+}
+
+// RateLimitedError is returned instead of waiting longer than the client's MaxRateLimitWait
+// for the rate limit of a host, no request was sent
+type RateLimitedError struct {
+	// host or configured domain whose rate limit was reached
+	Host string
+	// wait until the rate limit would allow the request
+	Delay time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("rate limit of %s reached, next request allowed in %s", e.Host, e.Delay.Round(time.Second))
+}
+
+// NotAFeedError is returned when the response body is not a feed the parser package can read,
+// e.g. an HTML page or a Cloudflare challenge
+type NotAFeedError struct {
+	StatusCode  int
+	ContentType string
+	Snippet     string
+	Err         error
+}
+
+func (e *NotAFeedError) Error() string {
+	return fmt.Sprintf("not a feed (Content-Type %q): %v: %q", e.ContentType, e.Err, e.Snippet)
+}
+
+func (e *NotAFeedError) Unwrap() error {
+	return e.Err
+}
+
+// TooLargeError is returned when the response body is larger than the allowed size
+type TooLargeError struct {
+	StatusCode int
+	Limit      int64
+}
+
+func (e *TooLargeError) Error() string {
+	return fmt.Sprintf("response body larger than %d bytes", e.Limit)
+}
+
+// networkError wraps errors of requests that never got a response
+type networkError struct {
+	Err error
+}
+
+func (e *networkError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *networkError) Unwrap() error {
+	return e.Err
+}
+
+// responseError wraps any other error that happened after a response was received
+type responseError struct {
+	StatusCode int
+	Err        error
+}
+
+func (e *responseError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *responseError) Unwrap() error {
+	return e.Err
+}
+
+func (e *HTTPStatusError) statusCode() int { return e.StatusCode }
+func (e *NotAFeedError) statusCode() int   { return e.StatusCode }
+func (e *TooLargeError) statusCode() int   { return e.StatusCode }
+func (e *responseError) statusCode() int   { return e.StatusCode }
+
+type statusCoder interface {
+	statusCode() int
+}
+
+// StatusCode returns the HTTP status of a failed fetch, 0 when no response was received
+func StatusCode(err error) int {
+	var coder statusCoder
+	if errors.As(err, &coder) {
+		return coder.statusCode()
+	}
+	return 0
+}
+
+// ErrorKind classifies a fetch error as one of the Kind constants
+func ErrorKind(err error) string {
+	var statusErr *HTTPStatusError
+	var notAFeedErr *NotAFeedError
+	var tooLargeErr *TooLargeError
+	var netErr *networkError
+	var timeoutErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return KindTimeout
+	case errors.As(err, &timeoutErr) && timeoutErr.Timeout():
+		return KindTimeout
+	case errors.As(err, &statusErr):
+		return KindHTTPStatus
+	case errors.As(err, &notAFeedErr):
+		return KindNotAFeed
+	case errors.As(err, &tooLargeErr):
+		return KindTooLarge
+	case errors.As(err, &netErr):
+		return KindNetwork
+	}
+	return KindOther
+}
+
+// RetryAfter returns how long the server, or the rate limit of its host, asks to wait before
+// the next fetch, 0 when neither does
+func RetryAfter(err error) time.Duration {
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.RetryAfter
+	}
+	var rateLimitedErr *RateLimitedError
+	if errors.As(err, &rateLimitedErr) {
+		return rateLimitedErr.Delay
+	}
+	return 0
+}
+
+// returns the start of a body as a single line, for error messages
+func snippet(body []byte) string {
+	const maxSnippet = 200
+	if len(body) > maxSnippet {
+		body = body[:maxSnippet]
+	}
+	return strings.Join(strings.Fields(strings.ToValidUTF8(string(body), "")), " ")
+}
@@ -0,0 +1,374 @@
+package fetcher
+
+import (
+	"GoBlogAggregator/internal/parser"
+	"GoBlogAggregator/internal/sanitize"
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andybalholm/brotli"
+)
+
+// defaults used for the Options left at zero
+const (
+	DefaultConnectTimeout = 10 * time.Second
+	DefaultTimeout        = 30 * time.Second
+	DefaultMaxBodySize    = 10 << 20
+)
+
+// Options limits every fetch made by a Client
+type Options struct {
+	// time allowed to establish the connection, including the TLS handshake
+	ConnectTimeout time.Duration
+	// time allowed for the whole request, including reading the body
+	Timeout time.Duration
+	// maximum size of the decompressed body, larger responses fail with a TooLargeError
+	MaxBodySize int64
+	// rate limits of domains, keyed by domain, a limit also applies to the domain's subdomains
+	HostRateLimits map[string]RateLimit
+	// rate limit of every other host, DefaultRequestsPerMinute and DefaultBurst when zero
+	DefaultRateLimit RateLimit
+	// longest a request waits for the rate limit of its host, requests that would wait longer
+	// fail with a RateLimitedError, 0 waits as long as needed
+	MaxRateLimitWait time.Duration
+}
+
+// Client fetches feeds, the zero value is not usable, use NewClient
+type Client struct {
+	transport   *http.Transport
+	timeout     time.Duration
+	maxBodySize int64
+	limiter     *hostLimiter
+}
+
+// NewClient returns a Client applying the given limits
+func NewClient(opts Options) *Client {
+	if opts.ConnectTimeout <= 0 {
+		opts.ConnectTimeout = DefaultConnectTimeout
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = DefaultMaxBodySize
+	}
+	dialer := &net.Dialer{Timeout: opts.ConnectTimeout}
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   opts.ConnectTimeout,
+		ResponseHeaderTimeout: opts.Timeout,
+		// decompression is done by decodeBody, so deflate and brotli are handled too
+		DisableCompression: true,
+	}
+	return &Client{
+		transport:   transport,
+		timeout:     opts.Timeout,
+		maxBodySize: opts.MaxBodySize,
+		limiter:     newHostLimiter(opts.HostRateLimits, opts.DefaultRateLimit, opts.MaxRateLimitWait),
+	}
+}
+
+// Result of a feed fetch, Feed is nil when the server answered 304 Not Modified
+type Result struct {
+	Feed         *parser.Feed
+	StatusCode   int
+	NotModified  bool
+	ETag         string
+	LastModified string
+	// set when the feed moved, every redirect on the way was permanent (301/308)
+	PermanentURL string
+	// Cache-Control max-age of the response, 0 when absent
+	MaxAge time.Duration
+}
+
+// FetchFeed fetches a feed from the given URL, the format is detected by the parser package
+// etag and lastModified are the validators of the previous fetch, used for a conditional GET
+// errors are one of HTTPStatusError, NotAFeedError or TooLargeError once a response was received
+// the call blocks while the rate limit of the feed's host is exhausted
+func (c *Client) FetchFeed(ctx context.Context, feedURL string, etag string, lastModified string) (*Result, error) {
+	// follow redirects, remembering where the feed permanently moved to
+	permanentURL := ""
+	onlyPermanent := true
+	checkRedirect := func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return fmt.Errorf("stopped after 10 redirects")
+		}
+		status := req.Response.StatusCode
+		if status != http.StatusMovedPermanently && status != http.StatusPermanentRedirect {
+			onlyPermanent = false
+		}
+		if onlyPermanent {
+			permanentURL = req.URL.String()
+		}
+		return nil
+	}
+	header := http.Header{}
+	if etag != "" {
+		header.Add("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		header.Add("If-Modified-Since", lastModified)
+	}
+	res, body, err := c.get(ctx, feedURL, header, checkRedirect)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusNotModified {
+		result := &Result{
+			StatusCode:   res.StatusCode,
+			NotModified:  true,
+			ETag:         etag,
+			LastModified: lastModified,
+			PermanentURL: permanentURL,
+			MaxAge:       cacheMaxAge(res.Header),
+		}
+		return result, nil
+	}
+
+	feed, err := ParseFeed(res.Request.URL.String(), res.Header.Get("Content-Type"), body)
+	if err != nil {
+		var notAFeedErr *NotAFeedError
+		if errors.As(err, &notAFeedErr) {
+			notAFeedErr.StatusCode = res.StatusCode
+		}
+		return nil, err
+	}
+	result := &Result{
+		Feed:         feed,
+		StatusCode:   res.StatusCode,
+		ETag:         res.Header.Get("ETag"),
+		LastModified: res.Header.Get("Last-Modified"),
+		PermanentURL: permanentURL,
+		MaxAge:       cacheMaxAge(res.Header),
+	}
+	return result, nil
+}
+
+// sends a GET through the host rate limiter and returns the response with its decoded body,
+// the body is nil for a 304, other statuses outside 2xx fail with an HTTPStatusError
+// checkRedirect is used as in http.Client, nil follows up to 10 redirects
+func (c *Client) get(ctx context.Context, rawURL string, header http.Header, checkRedirect func(*http.Request, []*http.Request) error) (*http.Response, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = c.limiter.Wait(ctx, rawURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	client := &http.Client{
+		Transport:     c.transport,
+		Timeout:       c.timeout,
+		CheckRedirect: checkRedirect,
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("User-Agent", "Gator")
+	req.Header.Set("Accept-Encoding", "gzip, deflate, br")
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, nil, &networkError{Err: err}
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotModified {
+		return res, nil, nil
+	}
+
+	bodyReader, err := decodeBody(res)
+	if err != nil {
+		return nil, nil, &responseError{StatusCode: res.StatusCode, Err: err}
+	}
+	defer bodyReader.Close()
+	// read one byte past the limit to tell a body of exactly maxBodySize from a larger one,
+	// the limit applies after decompression so a small compressed body cannot expand without bound
+	body, err := io.ReadAll(io.LimitReader(bodyReader, c.maxBodySize+1))
+	if err != nil {
+		return nil, nil, &responseError{StatusCode: res.StatusCode, Err: err}
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, nil, &HTTPStatusError{
+			StatusCode: res.StatusCode,
+			Status:     res.Status,
+			Snippet:    snippet(body),
+			RetryAfter: retryAfter(res, time.Now()),
+		}
+	}
+	if int64(len(body)) > c.maxBodySize {
+		return nil, nil, &TooLargeError{StatusCode: res.StatusCode, Limit: c.maxBodySize}
+	}
+	return res, body, nil
+}
+
+// ParseFeed parses a feed document the way FetchFeed does, for bodies received some other way,
+// e.g. pushed by a WebSub hub, failures are returned as a NotAFeedError
+// titles are made plain text and the HTML of descriptions and content sanitized, relative links
+// resolve against the item's link, which itself resolves against the site link and feedURL,
+// feedURL stands in for a missing site or item link
+func ParseFeed(feedURL string, contentType string, body []byte) (*parser.Feed, error) {
+	// the Content-Type is not trusted up front, some servers send feeds as text/html or text/plain
+	feed, err := parser.Parse(contentType, body)
+	if err != nil {
+		if isHTML(contentType) {
+			err = fmt.Errorf("got an HTML page: %w", err)
+		}
+		return nil, &NotAFeedError{
+			ContentType: contentType,
+			Snippet:     snippet(body),
+			Err:         err,
+		}
+	}
+	siteURL := resolveURL(feedURL, feed.Link)
+	feed.Link = siteURL
+	if siteURL == "" {
+		// without a site link, links are relative to the feed itself
+		siteURL = feedURL
+	}
+	feed.Title = sanitize.Title(feed.Title)
+	feed.Description = sanitize.Text(feed.Description)
+	for i := range feed.Entries {
+		entry := &feed.Entries[i]
+		entry.Link = resolveURL(siteURL, entry.Link)
+		entry.Title = sanitize.Title(entry.Title)
+		baseURL := entry.Link
+		if baseURL == "" {
+			baseURL = siteURL
+		}
+		entry.Description = sanitize.HTML(entry.Description, baseURL)
+		entry.Content = sanitize.HTML(entry.Content, baseURL)
+		entry.Enclosures = resolveEnclosures(baseURL, entry.Enclosures)
+	}
+	return feed, nil
+}
+
+// returns the enclosures with their URLs and thumbnails resolved against base,
+// enclosures that still have no http(s) URL cannot be downloaded and are dropped
+func resolveEnclosures(base string, enclosures []parser.Enclosure) []parser.Enclosure {
+	resolved := []parser.Enclosure{}
+	seen := map[string]bool{}
+	for _, enclosure := range enclosures {
+		enclosure.URL = resolveURL(base, enclosure.URL)
+		if !isHTTPURL(enclosure.URL) || seen[enclosure.URL] {
+			continue
+		}
+		seen[enclosure.URL] = true
+		enclosure.Thumbnail = resolveURL(base, enclosure.Thumbnail)
+		if !isHTTPURL(enclosure.Thumbnail) {
+			enclosure.Thumbnail = ""
+		}
+		resolved = append(resolved, enclosure)
+	}
+	return resolved
+}
+
+// reports whether rawURL is an absolute http or https URL
+func isHTTPURL(rawURL string) bool {
+	parsedURL, err := url.Parse(rawURL)
+	return err == nil && (parsedURL.Scheme == "http" || parsedURL.Scheme == "https") && parsedURL.Host != ""
+}
+
+// returns ref resolved against base, ref unchanged when either is not a valid URL
+func resolveURL(base string, ref string) string {
+	ref = strings.TrimSpace(ref)
+	baseURL, err := url.Parse(base)
+	if err != nil || ref == "" {
+		return ref
+	}
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return baseURL.ResolveReference(refURL).String()
+}
+
+// reports whether the Content-Type is an HTML page rather than a feed
+func isHTML(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "text/html" || mediaType == "application/xhtml+xml")
+}
+
+// returns a reader of the decompressed body according to the Content-Encoding
+func decodeBody(res *http.Response) (io.ReadCloser, error) {
+	switch strings.ToLower(strings.TrimSpace(res.Header.Get("Content-Encoding"))) {
+	case "", "identity":
+		return io.NopCloser(res.Body), nil
+	case "gzip", "x-gzip":
+		return gzip.NewReader(res.Body)
+	case "deflate":
+		return newDeflateReader(res.Body), nil
+	case "br":
+		return io.NopCloser(brotli.NewReader(res.Body)), nil
+	default:
+		return nil, fmt.Errorf("unsupported Content-Encoding %q", res.Header.Get("Content-Encoding"))
+	}
+}
+
+// HTTP deflate is meant to be zlib wrapped, but many servers send raw deflate,
+// the zlib header is checked to pick the right decoder
+func newDeflateReader(body io.Reader) io.ReadCloser {
+	buffered := bufio.NewReader(body)
+	header, err := buffered.Peek(2)
+	if err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		zlibReader, err := zlib.NewReader(buffered)
+		if err == nil {
+			return zlibReader
+		}
+	}
+	return flate.NewReader(buffered)
+}
+
+// returns the max-age directive of the Cache-Control header, 0 when absent or when caching is forbidden
+func cacheMaxAge(header http.Header) time.Duration {
+	maxAge := time.Duration(0)
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-cache", "no-store":
+			return 0
+		case "max-age":
+			seconds, err := strconv.Atoi(strings.Trim(value, `"`))
+			if err == nil && seconds > 0 {
+				maxAge = time.Duration(seconds) * time.Second
+			}
+		}
+	}
+	return maxAge
+}
+
+// returns the Retry-After delay of a 429 or 503 response, given in seconds or as an HTTP date,
+// 0 when absent or already passed
+func retryAfter(res *http.Response, now time.Time) time.Duration {
+	if res.StatusCode != http.StatusTooManyRequests && res.StatusCode != http.StatusServiceUnavailable {
+		return 0
+	}
+	value := strings.TrimSpace(res.Header.Get("Retry-After"))
+	if value == "" {
+		return 0
+	}
+	seconds, err := strconv.Atoi(value)
+	if err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	date, err := http.ParseTime(value)
+	if err != nil || !date.After(now) {
+		return 0
+	}
+	return date.Sub(now)
+}
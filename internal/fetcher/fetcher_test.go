@@ -0,0 +1,40 @@
+package fetcher
+
+import "testing"
+
+func TestParseFeedResolvesEnclosures(t *testing.T) {
+	const atom = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd">
+  <title>Podcast</title>
+  <entry>
+    <id>urn:ep1</id>
+    <title>Episode 1</title>
+    <link href="/episodes/1"/>
+    <link rel="enclosure" href="/media/ep1.mp3" type="audio/mpeg" length="1234"/>
+    <link rel="enclosure" href="javascript:alert(1)" type="audio/mpeg"/>
+    <itunes:image href="cover.jpg"/>
+  </entry>
+</feed>`
+	feed, err := ParseFeed("https://podcast.example.com/feed.atom", "application/atom+xml", []byte(atom))
+	if err != nil {
+		t.Fatalf("ParseFeed: %v", err)
+	}
+	if len(feed.Entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(feed.Entries))
+	}
+	entry := feed.Entries[0]
+	if entry.Link != "https://podcast.example.com/episodes/1" {
+		t.Errorf("Link = %q", entry.Link)
+	}
+	if len(entry.Enclosures) != 1 {
+		t.Fatalf("got enclosures %+v, want only the mp3", entry.Enclosures)
+	}
+	enclosure := entry.Enclosures[0]
+	if enclosure.URL != "https://podcast.example.com/media/ep1.mp3" {
+		t.Errorf("URL = %q", enclosure.URL)
+	}
+	// relative to the entry's link, like the links in its HTML
+	if enclosure.Thumbnail != "https://podcast.example.com/episodes/cover.jpg" {
+		t.Errorf("Thumbnail = %q", enclosure.Thumbnail)
+	}
+}
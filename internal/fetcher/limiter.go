@@ -0,0 +1,116 @@
+package fetcher
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// default politeness towards a single host
+const (
+	DefaultRequestsPerMinute = 30
+	DefaultBurst             = 5
+)
+
+// RateLimit is a token bucket refilled at RequestsPerMinute holding up to Burst requests
+type RateLimit struct {
+	RequestsPerMinute float64
+	Burst             int
+}
+
+// hostLimiter keeps one token bucket per host,
+// hosts under a configured domain share that domain's bucket
+type hostLimiter struct {
+	mu           sync.Mutex
+	limiters     map[string]*rate.Limiter
+	domainLimits map[string]RateLimit
+	defaultLimit RateLimit
+	// longest Wait before failing with a RateLimitedError, 0 waits as long as needed
+	maxWait time.Duration
+}
+
+func newHostLimiter(domainLimits map[string]RateLimit, defaultLimit RateLimit, maxWait time.Duration) *hostLimiter {
+	if defaultLimit.RequestsPerMinute <= 0 {
+		defaultLimit.RequestsPerMinute = DefaultRequestsPerMinute
+	}
+	if defaultLimit.Burst <= 0 {
+		defaultLimit.Burst = DefaultBurst
+	}
+	normalized := make(map[string]RateLimit, len(domainLimits))
+	for domain, limit := range domainLimits {
+		normalized[strings.ToLower(strings.TrimPrefix(domain, "."))] = limit
+	}
+	return &hostLimiter{
+		limiters:     make(map[string]*rate.Limiter),
+		domainLimits: normalized,
+		defaultLimit: defaultLimit,
+		maxWait:      maxWait,
+	}
+}
+
+// returns the bucket key and limit of a host, the most specific configured domain wins,
+// e.g. "foo.substack.com" uses the limit of "substack.com"
+func (h *hostLimiter) limitFor(host string) (string, RateLimit) {
+	host = strings.ToLower(host)
+	for domain := host; domain != ""; {
+		if limit, ok := h.domainLimits[domain]; ok {
+			return domain, limit
+		}
+		_, parent, found := strings.Cut(domain, ".")
+		if !found {
+			break
+		}
+		domain = parent
+	}
+	return host, h.defaultLimit
+}
+
+// Wait blocks until a request to the URL's host is allowed or ctx is done, a wait longer
+// than maxWait is not started and fails with a RateLimitedError
+func (h *hostLimiter) Wait(ctx context.Context, rawURL string) error {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	key, limit := h.limitFor(parsedURL.Hostname())
+
+	h.mu.Lock()
+	limiter, ok := h.limiters[key]
+	if !ok {
+		every := rate.Inf
+		if limit.RequestsPerMinute > 0 {
+			every = rate.Every(time.Duration(float64(time.Minute) / limit.RequestsPerMinute))
+		}
+		burst := limit.Burst
+		if burst <= 0 {
+			burst = 1
+		}
+		limiter = rate.NewLimiter(every, burst)
+		h.limiters[key] = limiter
+	}
+	h.mu.Unlock()
+
+	reservation := limiter.Reserve()
+	delay := reservation.Delay()
+	if h.maxWait > 0 && delay > h.maxWait {
+		// gives the token back, so requests within the limit are not delayed by this one
+		reservation.Cancel()
+		return &RateLimitedError{Host: key, Delay: delay}
+	}
+	if delay == 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		reservation.Cancel()
+		return ctx.Err()
+	}
+}
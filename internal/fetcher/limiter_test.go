@@ -0,0 +1,37 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHostLimiterMaxWait(t *testing.T) {
+	limits := map[string]RateLimit{"example.com": {RequestsPerMinute: 1, Burst: 1}}
+	limiter := newHostLimiter(limits, RateLimit{}, time.Second)
+	ctx := context.Background()
+
+	err := limiter.Wait(ctx, "https://a.example.com/feed")
+	if err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+	// the subdomain shares the bucket of example.com, which refills once a minute
+	err = limiter.Wait(ctx, "https://b.example.com/feed")
+	var rateLimitedErr *RateLimitedError
+	if !errors.As(err, &rateLimitedErr) {
+		t.Fatalf("second request: got %v, want a RateLimitedError", err)
+	}
+	if rateLimitedErr.Host != "example.com" || rateLimitedErr.Delay <= time.Second {
+		t.Errorf("got %+v, want example.com with a delay over the max wait", rateLimitedErr)
+	}
+	if RetryAfter(err) != rateLimitedErr.Delay {
+		t.Errorf("RetryAfter = %v, want %v", RetryAfter(err), rateLimitedErr.Delay)
+	}
+
+	// other hosts have their own bucket
+	err = limiter.Wait(ctx, "https://other.org/feed")
+	if err != nil {
+		t.Errorf("other host: %v", err)
+	}
+}
@@ -0,0 +1,154 @@
+package parser
+
+import (
+	"strings"
+	"time"
+)
+
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
+type atomFeed struct {
+	Title    atomText    `xml:"title"`
+	Subtitle atomText    `xml:"subtitle"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+	// the syndication module is sometimes used in Atom feeds too
+	UpdatePeriod    string `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`
+	UpdateFrequency string `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"`
+}
+
+type atomEntry struct {
+	mediaFields
+	ID        string     `xml:"id"`
+	Title     atomText   `xml:"title"`
+	Links     []atomLink `xml:"link"`
+	Updated   string     `xml:"updated"`
+	Published string     `xml:"published"`
+	Summary   atomText   `xml:"summary"`
+	Content   atomText   `xml:"content"`
+	Authors   []struct {
+		Name string `xml:"name"`
+	} `xml:"author"`
+}
+
+// Atom text constructs are plain text, escaped html or inline xhtml markup
+type atomText struct {
+	Type     string `xml:"type,attr"`
+	Text     string `xml:",chardata"`
+	InnerXML string `xml:",innerxml"`
+}
+
+type atomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+// returns the text content, keeping the markup of xhtml content
+func (t atomText) String() string {
+	if t.Type == "xhtml" {
+		return strings.TrimSpace(t.InnerXML)
+	}
+	return strings.TrimSpace(t.Text)
+}
+
+// returns the alternate link of an Atom element, a link without rel counts as alternate
+func atomAlternateLink(links []atomLink) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return link.Href
+		}
+	}
+	return ""
+}
+
+// returns the first link with the given rel, used for the WebSub hub and self links
+func atomLinkByRel(links []atomLink, rel string) string {
+	for _, link := range links {
+		if link.Rel == rel {
+			return strings.TrimSpace(link.Href)
+		}
+	}
+	return ""
+}
+
+// converts an Atom or JSON Feed date (RFC3339) to the RFC1123 format used by RSS pubDate
+func rfc3339ToPubDate(date string) string {
+	date = strings.TrimSpace(date)
+	t, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return date
+	}
+	return t.UTC().Format(time.RFC1123)
+}
+
+// atomParser reads Atom 1.0 documents
+type atomParser struct{}
+
+func (atomParser) Name() string {
+	return "atom"
+}
+
+func (atomParser) Detect(contentType string, data []byte) bool {
+	root, err := xmlRootElement(data)
+	if err != nil {
+		return false
+	}
+	return root.Local == "feed" && root.Space == atomNamespace
+}
+
+func (atomParser) Parse(data []byte) (*Feed, error) {
+	doc := &atomFeed{}
+	err := unmarshalXML(data, doc)
+	if err != nil {
+		return nil, err
+	}
+
+	feed := &Feed{
+		Title:       doc.Title.String(),
+		Link:        atomAlternateLink(doc.Links),
+		Description: doc.Subtitle.String(),
+		Hub:         atomLinkByRel(doc.Links, "hub"),
+		Self:        atomLinkByRel(doc.Links, "self"),
+	}
+	feed.Hints.UpdateInterval = syndicationInterval(doc.UpdatePeriod, doc.UpdateFrequency)
+	for _, atomEntry := range doc.Entries {
+		entry := Entry{
+			ID:          strings.TrimSpace(atomEntry.ID),
+			Title:       atomEntry.Title.String(),
+			Link:        atomAlternateLink(atomEntry.Links),
+			Description: atomEntry.Summary.String(),
+			Content:     atomEntry.Content.String(),
+		}
+		if entry.Description == "" {
+			entry.Description = atomEntry.Content.String()
+		}
+		// prefer the original publication date over the last update
+		if atomEntry.Published != "" {
+			entry.PubDate = rfc3339ToPubDate(atomEntry.Published)
+		} else {
+			entry.PubDate = rfc3339ToPubDate(atomEntry.Updated)
+		}
+		names := []string{}
+		for _, author := range atomEntry.Authors {
+			if author.Name != "" {
+				names = append(names, author.Name)
+			}
+		}
+		entry.Author = strings.Join(names, ", ")
+		enclosures := []Enclosure{}
+		for _, link := range atomEntry.Links {
+			if link.Rel == "enclosure" {
+				enclosures = append(enclosures, Enclosure{
+					URL:    strings.TrimSpace(link.Href),
+					Length: parseLength(link.Length),
+					Type:   strings.TrimSpace(link.Type),
+				})
+			}
+		}
+		entry.Enclosures = atomEntry.mediaFields.enclosures(enclosures)
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return feed, nil
+}
@@ -0,0 +1,52 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"mime"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+// encoding attribute of the XML declaration
+var xmlDeclEncoding = regexp.MustCompile(`^(\s*<\?xml[^>]*?encoding\s*=\s*)["'][^"']*["']`)
+
+// returns a decoder that converts documents declaring a non-UTF-8 encoding,
+// e.g. encoding="ISO-8859-1" or windows-1252, to UTF-8
+func newXMLDecoder(data []byte) *xml.Decoder {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.CharsetReader = charset.NewReaderLabel
+	return decoder
+}
+
+// unmarshal an XML document in any encoding known to the charset package
+func unmarshalXML(data []byte, v any) error {
+	return newXMLDecoder(data).Decode(v)
+}
+
+// toUTF8 converts the document to UTF-8 when the HTTP Content-Type declares another charset,
+// the XML declaration is rewritten to match since the HTTP header takes precedence over it
+func toUTF8(contentType string, data []byte) ([]byte, error) {
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return data, nil
+	}
+	label := strings.ToLower(strings.TrimSpace(params["charset"]))
+	if label == "" || label == "utf-8" || label == "utf8" {
+		return data, nil
+	}
+	reader, err := charset.NewReaderLabel(label, bytes.NewReader(data))
+	if err != nil {
+		// unknown charset label, leave it to the XML declaration
+		return data, nil
+	}
+	converted, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	converted = bytes.TrimPrefix(converted, []byte("\xef\xbb\xbf"))
+	return xmlDeclEncoding.ReplaceAll(converted, []byte(`${1}"UTF-8"`)), nil
+}
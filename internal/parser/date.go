@@ -0,0 +1,158 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// offsets of the named zones commonly found in feed dates, time.Parse only knows the local zone
+var zoneOffsets = map[string]string{
+	"UT":   "+0000",
+	"UTC":  "+0000",
+	"GMT":  "+0000",
+	"Z":    "+0000",
+	"EST":  "-0500",
+	"EDT":  "-0400",
+	"CST":  "-0600",
+	"CDT":  "-0500",
+	"MST":  "-0700",
+	"MDT":  "-0600",
+	"PST":  "-0800",
+	"PDT":  "-0700",
+	"AKST": "-0900",
+	"AKDT": "-0800",
+	"HST":  "-1000",
+	"BST":  "+0100",
+	"IST":  "+0530",
+	"WET":  "+0000",
+	"WEST": "+0100",
+	"CET":  "+0100",
+	"CEST": "+0200",
+	"EET":  "+0200",
+	"EEST": "+0300",
+	"MSK":  "+0300",
+	"JST":  "+0900",
+	"KST":  "+0900",
+	"HKT":  "+0800",
+	"SGT":  "+0800",
+	"AWST": "+0800",
+	"ACST": "+0930",
+	"AEST": "+1000",
+	"AEDT": "+1100",
+	"NZST": "+1200",
+	"NZDT": "+1300",
+}
+
+// layouts tried in order after the date has been normalized,
+// weekdays are stripped and named zones replaced by numeric offsets
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05 -07:00",
+	"2006-01-02 15:04:05-07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02 3:04:05 PM",
+	"2006-01-02 3:04 PM",
+	"2006-01-02",
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 -07:00",
+	"2 Jan 2006 15:04 -0700",
+	"2 Jan 2006 15:04:05",
+	"2 Jan 2006 15:04",
+	"2 Jan 06 15:04:05 -0700",
+	"2 Jan 06 15:04 -0700",
+	"2 Jan 06 15:04:05",
+	"2 January 2006 15:04:05 -0700",
+	"2 January 2006 15:04 -0700",
+	"2 January 2006 15:04:05",
+	"2 Jan 2006 3:04:05 PM -0700",
+	"2 Jan 2006 3:04:05 PM",
+	"2 Jan 2006 3:04 PM -0700",
+	"2 Jan 2006 3:04 PM",
+	"2 January 2006 3:04:05 PM -0700",
+	"2 January 2006 3:04:05 PM",
+	"2 January 2006 3:04 PM -0700",
+	"2 January 2006 3:04 PM",
+	"2 Jan 2006",
+	"2 January 2006",
+	"Jan 2 2006 15:04:05 -0700",
+	"Jan 2 2006 15:04:05",
+	"Jan 2 15:04:05 -0700 2006",
+	"Jan 2 15:04:05 2006",
+	"January 2 2006 15:04:05 -0700",
+	"January 2 2006 15:04",
+	"Jan 2 2006 3:04:05 PM -0700",
+	"Jan 2 2006 3:04:05 PM",
+	"Jan 2 2006 3:04 PM -0700",
+	"Jan 2 2006 3:04 PM",
+	"January 2 2006 3:04:05 PM -0700",
+	"January 2 2006 3:04:05 PM",
+	"January 2 2006 3:04 PM -0700",
+	"January 2 2006 3:04 PM",
+	"January 2 2006",
+	"Jan 2 2006",
+	"2006/01/02 15:04:05 -0700",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+	"02.01.2006 15:04:05 -0700",
+	"02.01.2006",
+}
+
+var (
+	weekdayPrefix  = regexp.MustCompile(`(?i)^(mon|tue|wed|thu|fri|sat|sun)[a-z]*\.?,?\s+`)
+	zoneComment    = regexp.MustCompile(`\s*\([^)]*\)$`)
+	ordinalSuffix  = regexp.MustCompile(`(\d)(st|nd|rd|th)\b`)
+	repeatedSpaces = regexp.MustCompile(`\s+`)
+)
+
+// normalizeDate strips the parts of a date that vary the most between feeds
+func normalizeDate(value string) string {
+	value = strings.TrimSpace(value)
+	value = repeatedSpaces.ReplaceAllString(value, " ")
+	value = zoneComment.ReplaceAllString(value, "")
+	value = weekdayPrefix.ReplaceAllString(value, "")
+	value = ordinalSuffix.ReplaceAllString(value, "$1")
+	value = strings.ReplaceAll(value, ",", "")
+	fields := strings.Fields(value)
+	for i, field := range fields {
+		if offset, ok := zoneOffsets[strings.ToUpper(field)]; ok && i > 0 {
+			fields[i] = offset
+		}
+		// the PM layouts only match upper case, written am, a.m. or AM
+		switch strings.ToUpper(strings.ReplaceAll(field, ".", "")) {
+		case "AM":
+			fields[i] = "AM"
+		case "PM":
+			fields[i] = "PM"
+		}
+	}
+	value = strings.Join(fields, " ")
+	// numeric offsets written as GMT+0200
+	if i := strings.LastIndex(value, " GMT+"); i != -1 {
+		value = value[:i] + " +" + value[i+len(" GMT+"):]
+	} else if i := strings.LastIndex(value, " GMT-"); i != -1 {
+		value = value[:i] + " -" + value[i+len(" GMT-"):]
+	}
+	return value
+}
+
+// ParseDate parses the publication dates found in the wild, returned in UTC
+func ParseDate(value string) (time.Time, error) {
+	normalized := normalizeDate(value)
+	if normalized == "" {
+		return time.Time{}, fmt.Errorf("empty date")
+	}
+	for _, layout := range dateLayouts {
+		t, err := time.Parse(layout, normalized)
+		if err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date format: %q", value)
+}
@@ -0,0 +1,165 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Media RSS elements, used by RSS podcasts and YouTube's Atom feeds
+type mediaContent struct {
+	URL        string           `xml:"url,attr"`
+	Type       string           `xml:"type,attr"`
+	FileSize   string           `xml:"fileSize,attr"`
+	Duration   string           `xml:"duration,attr"`
+	Thumbnails []mediaThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+}
+
+type mediaThumbnail struct {
+	URL string `xml:"url,attr"`
+}
+
+// iTunes and Media RSS elements of an RSS item or Atom entry
+// embedded before any unnamespaced field of the same local name, e.g. Atom <content>,
+// which would otherwise match these elements too
+type mediaFields struct {
+	ITunesDuration string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+	ITunesSeason   string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd season"`
+	ITunesEpisode  string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd episode"`
+	ITunesImage    struct {
+		Href string `xml:"href,attr"`
+	} `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+	MediaContents   []mediaContent   `xml:"http://search.yahoo.com/mrss/ content"`
+	MediaThumbnails []mediaThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	MediaGroups     []struct {
+		Contents   []mediaContent   `xml:"http://search.yahoo.com/mrss/ content"`
+		Thumbnails []mediaThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	} `xml:"http://search.yahoo.com/mrss/ group"`
+
+	// never read, they only keep these elements out of the item's title, description,
+	// summary and author, e.g. <itunes:title> holds the episode title without the show name
+	ITunesTitle           string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd title"`
+	ITunesAuthor          string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd author"`
+	ITunesSummary         string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd summary"`
+	ITunesSubtitle        string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd subtitle"`
+	GooglePlayAuthor      string `xml:"http://www.google.com/schemas/play-podcasts/1.0 author"`
+	GooglePlayDescription string `xml:"http://www.google.com/schemas/play-podcasts/1.0 description"`
+	MediaTitle            string `xml:"http://search.yahoo.com/mrss/ title"`
+	MediaDescription      string `xml:"http://search.yahoo.com/mrss/ description"`
+	MediaKeywords         string `xml:"http://search.yahoo.com/mrss/ keywords"`
+}
+
+// returns the given enclosures followed by the Media RSS ones, with the item level
+// iTunes episode data and thumbnail filled in
+func (m mediaFields) enclosures(enclosures []Enclosure) []Enclosure {
+	contents := m.MediaContents
+	thumbnails := m.MediaThumbnails
+	for _, group := range m.MediaGroups {
+		contents = append(contents, group.Contents...)
+		thumbnails = append(thumbnails, group.Thumbnails...)
+	}
+	for _, content := range contents {
+		enclosure := Enclosure{
+			URL:      strings.TrimSpace(content.URL),
+			Type:     strings.TrimSpace(content.Type),
+			Length:   parseLength(content.FileSize),
+			Duration: parseDuration(content.Duration),
+		}
+		if len(content.Thumbnails) > 0 {
+			enclosure.Thumbnail = strings.TrimSpace(content.Thumbnails[0].URL)
+		}
+		enclosures = append(enclosures, enclosure)
+	}
+
+	defaults := Enclosure{
+		Duration:  parseDuration(m.ITunesDuration),
+		Season:    parseNumber(m.ITunesSeason),
+		Episode:   parseNumber(m.ITunesEpisode),
+		Thumbnail: strings.TrimSpace(m.ITunesImage.Href),
+	}
+	if defaults.Thumbnail == "" && len(thumbnails) > 0 {
+		defaults.Thumbnail = strings.TrimSpace(thumbnails[0].URL)
+	}
+	return mergeEnclosures(enclosures, defaults)
+}
+
+// merges enclosures with the same URL, feeds often list a file both as <enclosure> and <media:content>,
+// and fills the fields they leave empty from defaults
+func mergeEnclosures(enclosures []Enclosure, defaults Enclosure) []Enclosure {
+	merged := []Enclosure{}
+	index := map[string]int{}
+	for _, enclosure := range enclosures {
+		if enclosure.URL == "" {
+			continue
+		}
+		i, ok := index[enclosure.URL]
+		if !ok {
+			index[enclosure.URL] = len(merged)
+			merged = append(merged, enclosure)
+			continue
+		}
+		merged[i] = fillEnclosure(merged[i], enclosure)
+	}
+	for i := range merged {
+		merged[i] = fillEnclosure(merged[i], defaults)
+	}
+	return merged
+}
+
+// returns the enclosure with its empty fields taken from other
+func fillEnclosure(enclosure Enclosure, other Enclosure) Enclosure {
+	if enclosure.Length == 0 {
+		enclosure.Length = other.Length
+	}
+	if enclosure.Type == "" {
+		enclosure.Type = other.Type
+	}
+	if enclosure.Duration == 0 {
+		enclosure.Duration = other.Duration
+	}
+	if enclosure.Season == 0 {
+		enclosure.Season = other.Season
+	}
+	if enclosure.Episode == 0 {
+		enclosure.Episode = other.Episode
+	}
+	if enclosure.Thumbnail == "" {
+		enclosure.Thumbnail = other.Thumbnail
+	}
+	return enclosure
+}
+
+// parses a duration given in seconds or as [[hh:]mm:]ss, as in itunes:duration, 0 when invalid
+func parseDuration(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	var total float64
+	for _, part := range strings.Split(value, ":") {
+		number, err := strconv.ParseFloat(part, 64)
+		if err != nil || number < 0 {
+			return 0
+		}
+		total = total*60 + number
+	}
+	return time.Duration(total * float64(time.Second))
+}
+
+// parses a size in bytes, 0 when absent or invalid
+func parseLength(value string) int64 {
+	length, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || length < 0 {
+		return 0
+	}
+	return length
+}
+
+// parses a season or episode number, 0 when absent or invalid
+func parseNumber(value string) int {
+	number, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || number < 0 {
+		return 0
+	}
+	return number
+}
@@ -0,0 +1,73 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RSS <skipHours>/<skipDays> elements
+type skipHints struct {
+	Hours []string `xml:"skipHours>hour"`
+	Days  []string `xml:"skipDays>day"`
+}
+
+// converts the RSS <ttl>, given in minutes
+func ttlDuration(ttl string) time.Duration {
+	minutes, err := strconv.Atoi(strings.TrimSpace(ttl))
+	if err != nil || minutes <= 0 {
+		return 0
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// converts sy:updatePeriod and sy:updateFrequency, the feed updates frequency times per period
+func syndicationInterval(period string, frequency string) time.Duration {
+	var periodDuration time.Duration
+	switch strings.ToLower(strings.TrimSpace(period)) {
+	case "":
+		if strings.TrimSpace(frequency) == "" {
+			return 0
+		}
+		// the period defaults to daily when only a frequency is given
+		periodDuration = 24 * time.Hour
+	case "hourly":
+		periodDuration = time.Hour
+	case "daily":
+		periodDuration = 24 * time.Hour
+	case "weekly":
+		periodDuration = 7 * 24 * time.Hour
+	case "monthly":
+		periodDuration = 30 * 24 * time.Hour
+	case "yearly":
+		periodDuration = 365 * 24 * time.Hour
+	default:
+		return 0
+	}
+	times := 1
+	if n, err := strconv.Atoi(strings.TrimSpace(frequency)); err == nil && n > 0 {
+		times = n
+	}
+	return periodDuration / time.Duration(times)
+}
+
+// converts skipHours (0-23, GMT) and skipDays (English weekday names)
+func (h skipHints) parse() ([]int, []time.Weekday) {
+	hours := []int{}
+	for _, hour := range h.Hours {
+		n, err := strconv.Atoi(strings.TrimSpace(hour))
+		// some feeds use 24 for midnight
+		if err == nil && n >= 0 && n <= 24 {
+			hours = append(hours, n%24)
+		}
+	}
+	days := []time.Weekday{}
+	for _, day := range h.Days {
+		for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+			if strings.EqualFold(strings.TrimSpace(day), weekday.String()) {
+				days = append(days, weekday)
+			}
+		}
+	}
+	return hours, days
+}
@@ -0,0 +1,195 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"mime"
+	"strings"
+	"time"
+)
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	FeedURL     string         `json:"feed_url"`
+	Description string         `json:"description"`
+	Items       []jsonFeedItem `json:"items"`
+	Hubs        []struct {
+		Type string `json:"type"`
+		URL  string `json:"url"`
+	} `json:"hubs"`
+}
+
+type jsonFeedItem struct {
+	ID            jsonFeedID       `json:"id"`
+	URL           string           `json:"url"`
+	ExternalURL   string           `json:"external_url"`
+	Title         string           `json:"title"`
+	ContentHTML   string           `json:"content_html"`
+	ContentText   string           `json:"content_text"`
+	Summary       string           `json:"summary"`
+	DatePublished string           `json:"date_published"`
+	DateModified  string           `json:"date_modified"`
+	Authors       []jsonFeedAuthor `json:"authors"`
+	// JSON Feed 1.0 used a single author object
+	Author      *jsonFeedAuthor `json:"author"`
+	Image       string          `json:"image"`
+	Attachments []struct {
+		URL               string  `json:"url"`
+		MimeType          string  `json:"mime_type"`
+		SizeInBytes       int64   `json:"size_in_bytes"`
+		DurationInSeconds float64 `json:"duration_in_seconds"`
+	} `json:"attachments"`
+}
+
+// item id, a string in the spec but some feeds publish numbers
+type jsonFeedID string
+
+func (id *jsonFeedID) UnmarshalJSON(data []byte) error {
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err := decoder.Decode(&value)
+	if err != nil {
+		return err
+	}
+	switch value := value.(type) {
+	case string:
+		*id = jsonFeedID(value)
+	case json.Number:
+		*id = jsonFeedID(value.String())
+	case nil:
+		*id = ""
+	default:
+		return fmt.Errorf("invalid item id: %s", data)
+	}
+	return nil
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// joins the author names of a JSON Feed item
+func (item jsonFeedItem) authorNames() string {
+	authors := item.Authors
+	if len(authors) == 0 && item.Author != nil {
+		authors = []jsonFeedAuthor{*item.Author}
+	}
+	names := []string{}
+	for _, author := range authors {
+		if author.Name != "" {
+			names = append(names, author.Name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// prefix of the version URL every JSON Feed starts with
+const jsonFeedVersionPrefix = "https://jsonfeed.org/version/"
+
+// jsonFeedParser reads JSON Feed 1.0 and 1.1 documents
+type jsonFeedParser struct{}
+
+func (jsonFeedParser) Name() string {
+	return "jsonfeed"
+}
+
+// detects a JSON Feed by Content-Type or by sniffing the body, other JSON documents
+// (application/json) are only taken when they carry the JSON Feed version URL
+func (jsonFeedParser) Detect(contentType string, data []byte) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err == nil && mediaType == "application/feed+json" {
+		return true
+	}
+	trimmed := bytes.TrimSpace(data)
+	if !bytes.HasPrefix(trimmed, []byte("{")) {
+		return false
+	}
+	// PHP's json_encode escapes slashes by default
+	return bytes.Contains(trimmed, []byte(jsonFeedVersionPrefix)) ||
+		bytes.Contains(trimmed, []byte(strings.ReplaceAll(jsonFeedVersionPrefix, "/", `\/`)))
+}
+
+func (jsonFeedParser) Parse(data []byte) (*Feed, error) {
+	doc := &jsonFeed{}
+	err := json.Unmarshal(data, doc)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(doc.Version, jsonFeedVersionPrefix) {
+		return nil, fmt.Errorf("not a JSON Feed: version %q", doc.Version)
+	}
+
+	feed := &Feed{
+		Title:       doc.Title,
+		Link:        doc.HomePageURL,
+		Description: doc.Description,
+		Self:        doc.FeedURL,
+	}
+	for _, hub := range doc.Hubs {
+		if strings.EqualFold(hub.Type, "WebSub") {
+			feed.Hub = hub.URL
+			break
+		}
+	}
+	for _, item := range doc.Items {
+		entry := Entry{
+			ID:          string(item.ID),
+			Title:       item.Title,
+			Link:        item.URL,
+			Description: item.ContentHTML,
+			Content:     item.ContentHTML,
+			Author:      item.authorNames(),
+		}
+		// content_text and summary are plain text, entries hold HTML
+		if entry.Content == "" {
+			entry.Content = textToHTML(item.ContentText)
+		}
+		if entry.Link == "" {
+			entry.Link = item.ExternalURL
+		}
+		if entry.Description == "" {
+			entry.Description = textToHTML(item.ContentText)
+		}
+		if entry.Description == "" {
+			entry.Description = textToHTML(item.Summary)
+		}
+		enclosures := []Enclosure{}
+		for _, attachment := range item.Attachments {
+			enclosures = append(enclosures, Enclosure{
+				URL:      strings.TrimSpace(attachment.URL),
+				Length:   attachment.SizeInBytes,
+				Type:     attachment.MimeType,
+				Duration: time.Duration(attachment.DurationInSeconds * float64(time.Second)),
+			})
+		}
+		entry.Enclosures = mergeEnclosures(enclosures, Enclosure{Thumbnail: item.Image})
+		if item.DatePublished != "" {
+			entry.PubDate = rfc3339ToPubDate(item.DatePublished)
+		} else {
+			entry.PubDate = rfc3339ToPubDate(item.DateModified)
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return feed, nil
+}
+
+// returns plain text as escaped HTML, a paragraph per blank line separated block
+func textToHTML(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	paragraphs := []string{}
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		lines := strings.Split(html.EscapeString(paragraph), "\n")
+		paragraphs = append(paragraphs, "<p>"+strings.Join(lines, "<br>")+"</p>")
+	}
+	return strings.Join(paragraphs, "\n")
+}
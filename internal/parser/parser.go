@@ -0,0 +1,129 @@
+package parser
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrUnknownFormat is returned when no registered parser recognizes a document
+var ErrUnknownFormat = errors.New("unrecognized feed format")
+
+// Feed is the normalized model every format parser returns
+type Feed struct {
+	Format      string
+	Title       string
+	Link        string
+	Description string
+	Entries     []Entry
+	Hints       Hints
+	// WebSub hub advertised by the feed and the topic URL to subscribe to, empty when absent
+	Hub  string
+	Self string
+}
+
+// Hints are the refresh hints a feed publishes, zero values when absent
+type Hints struct {
+	// RSS <ttl>
+	TTL time.Duration
+	// sy:updatePeriod divided by sy:updateFrequency
+	UpdateInterval time.Duration
+	// RSS <skipHours> in GMT and <skipDays>
+	SkipHours []int
+	SkipDays  []time.Weekday
+}
+
+// Entry is a single item/entry of a feed
+type Entry struct {
+	ID          string
+	Title       string
+	Link        string
+	Description string
+	// full body of the entry, empty when the feed only has the description
+	Content string
+	// publication date, converted to RFC1123 when the source format uses another layout
+	PubDate    string
+	Author     string
+	Enclosures []Enclosure
+}
+
+// Enclosure is a media file attached to an entry, e.g. a podcast episode, zero values when unknown
+type Enclosure struct {
+	URL string
+	// size in bytes
+	Length int64
+	// MIME type
+	Type     string
+	Duration time.Duration
+	Season   int
+	Episode  int
+	// URL of an image of the episode
+	Thumbnail string
+}
+
+// Parser detects and parses a single feed format
+type Parser interface {
+	// short name of the format, e.g. "rss" or "atom"
+	Name() string
+	// reports whether the document is in this parser's format
+	Detect(contentType string, data []byte) bool
+	Parse(data []byte) (*Feed, error)
+}
+
+var parsers = []Parser{
+	jsonFeedParser{},
+	atomParser{},
+	rdfParser{},
+	rssParser{},
+}
+
+// Register adds a parser for a new format, it is tried before the built in parsers
+func Register(p Parser) {
+	parsers = append([]Parser{p}, parsers...)
+}
+
+// Detect returns the first registered parser that recognizes the document
+func Detect(contentType string, data []byte) (Parser, error) {
+	for _, p := range parsers {
+		if p.Detect(contentType, data) {
+			return p, nil
+		}
+	}
+	return nil, ErrUnknownFormat
+}
+
+// Parse detects the format of a document and parses it into a Feed
+func Parse(contentType string, data []byte) (*Feed, error) {
+	data, err := toUTF8(contentType, data)
+	if err != nil {
+		return nil, err
+	}
+	p, err := Detect(contentType, data)
+	if err != nil {
+		return nil, err
+	}
+	feed, err := p.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	feed.Format = p.Name()
+	return feed, nil
+}
+
+// returns the name and namespace of the first element in an XML document
+func xmlRootElement(data []byte) (xml.Name, error) {
+	decoder := newXMLDecoder(data)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if err == io.EOF {
+				return xml.Name{}, nil
+			}
+			return xml.Name{}, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name, nil
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package parser
+
+import (
+	"strings"
+	"time"
+)
+
+const rdfNamespace = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+
+// RSS 1.0 documents have an rdf:RDF root with the items as siblings of the channel
+type rdfFeed struct {
+	Channel struct {
+		Title string `xml:"title"`
+		// must come before Link, as in rssFeed
+		AtomLinks       []atomLink `xml:"http://www.w3.org/2005/Atom link"`
+		Link            string     `xml:"link"`
+		Description     string     `xml:"description"`
+		UpdatePeriod    string     `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`
+		UpdateFrequency string     `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"`
+	} `xml:"channel"`
+	Item []rdfItem `xml:"item"`
+}
+
+type rdfItem struct {
+	About       string `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+	Date        string `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Creator     string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Encoded     string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+}
+
+// converts a dc:date (W3CDTF, which allows a bare date) to the RFC1123 format used by RSS pubDate
+func dcDateToPubDate(date string) string {
+	date = strings.TrimSpace(date)
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return rfc3339ToPubDate(date)
+	}
+	return t.UTC().Format(time.RFC1123)
+}
+
+// rdfParser reads RSS 1.0 (RDF) documents
+type rdfParser struct{}
+
+func (rdfParser) Name() string {
+	return "rdf"
+}
+
+func (rdfParser) Detect(contentType string, data []byte) bool {
+	root, err := xmlRootElement(data)
+	if err != nil {
+		return false
+	}
+	return root.Local == "RDF" && root.Space == rdfNamespace
+}
+
+func (rdfParser) Parse(data []byte) (*Feed, error) {
+	doc := &rdfFeed{}
+	err := unmarshalXML(data, doc)
+	if err != nil {
+		return nil, err
+	}
+
+	feed := &Feed{
+		Title:       doc.Channel.Title,
+		Link:        doc.Channel.Link,
+		Description: doc.Channel.Description,
+		Hub:         atomLinkByRel(doc.Channel.AtomLinks, "hub"),
+		Self:        atomLinkByRel(doc.Channel.AtomLinks, "self"),
+	}
+	feed.Hints.UpdateInterval = syndicationInterval(doc.Channel.UpdatePeriod, doc.Channel.UpdateFrequency)
+	for _, item := range doc.Item {
+		feed.Entries = append(feed.Entries, Entry{
+			ID:          item.About,
+			Title:       item.Title,
+			Link:        item.Link,
+			Description: item.Description,
+			Content:     strings.TrimSpace(item.Encoded),
+			PubDate:     dcDateToPubDate(item.Date),
+			Author:      item.Creator,
+		})
+	}
+	return feed, nil
+}
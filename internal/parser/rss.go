@@ -0,0 +1,102 @@
+package parser
+
+import "strings"
+
+type rssFeed struct {
+	Channel struct {
+		// podcasts repeat the show's title and description as <itunes:title> and
+		// <googleplay:description>, the embedded fields keep them out of Title and Description
+		mediaFields
+		Title string `xml:"title"`
+		// atom:link elements carry the WebSub hub and self links, listed before Link
+		// which would match links of any namespace
+		AtomLinks   []atomLink `xml:"http://www.w3.org/2005/Atom link"`
+		Link        string     `xml:"link"`
+		Description string     `xml:"description"`
+		Item        []rssItem  `xml:"item"`
+		TTL         string     `xml:"ttl"`
+		skipHints
+		UpdatePeriod    string `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`
+		UpdateFrequency string `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"`
+	} `xml:"channel"`
+}
+
+type rssItem struct {
+	mediaFields
+	// never read, must come before Link like Channel.AtomLinks, an item's <atom:link> would
+	// otherwise replace its <link>
+	AtomLinks   []atomLink `xml:"http://www.w3.org/2005/Atom link"`
+	GUID        string     `xml:"guid"`
+	Title       string     `xml:"title"`
+	Link        string     `xml:"link"`
+	Description string     `xml:"description"`
+	PubDate     string     `xml:"pubDate"`
+	Author      string     `xml:"author"`
+	Creator     string     `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	// full article of WordPress, Substack and most other blogs, description is a teaser there
+	Encoded    string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Enclosures []struct {
+		URL    string `xml:"url,attr"`
+		Length string `xml:"length,attr"`
+		Type   string `xml:"type,attr"`
+	} `xml:"enclosure"`
+}
+
+// rssParser reads RSS 0.9x and 2.0 documents
+type rssParser struct{}
+
+func (rssParser) Name() string {
+	return "rss"
+}
+
+func (rssParser) Detect(contentType string, data []byte) bool {
+	root, err := xmlRootElement(data)
+	if err != nil {
+		return false
+	}
+	return root.Local == "rss"
+}
+
+func (rssParser) Parse(data []byte) (*Feed, error) {
+	doc := &rssFeed{}
+	err := unmarshalXML(data, doc)
+	if err != nil {
+		return nil, err
+	}
+
+	feed := &Feed{
+		Title:       doc.Channel.Title,
+		Link:        doc.Channel.Link,
+		Description: doc.Channel.Description,
+		Hub:         atomLinkByRel(doc.Channel.AtomLinks, "hub"),
+		Self:        atomLinkByRel(doc.Channel.AtomLinks, "self"),
+	}
+	feed.Hints.TTL = ttlDuration(doc.Channel.TTL)
+	feed.Hints.UpdateInterval = syndicationInterval(doc.Channel.UpdatePeriod, doc.Channel.UpdateFrequency)
+	feed.Hints.SkipHours, feed.Hints.SkipDays = doc.Channel.skipHints.parse()
+	for _, item := range doc.Channel.Item {
+		entry := Entry{
+			ID:          item.GUID,
+			Title:       item.Title,
+			Link:        item.Link,
+			Description: item.Description,
+			Content:     strings.TrimSpace(item.Encoded),
+			PubDate:     item.PubDate,
+			Author:      item.Author,
+		}
+		if entry.Author == "" {
+			entry.Author = item.Creator
+		}
+		enclosures := []Enclosure{}
+		for _, enclosure := range item.Enclosures {
+			enclosures = append(enclosures, Enclosure{
+				URL:    strings.TrimSpace(enclosure.URL),
+				Length: parseLength(enclosure.Length),
+				Type:   strings.TrimSpace(enclosure.Type),
+			})
+		}
+		entry.Enclosures = item.mediaFields.enclosures(enclosures)
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return feed, nil
+}
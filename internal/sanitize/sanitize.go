@@ -0,0 +1,501 @@
+package sanitize
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// elements removed together with everything inside them
+var droppedElements = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Iframe:   true,
+	atom.Frame:    true,
+	atom.Frameset: true,
+	atom.Object:   true,
+	atom.Embed:    true,
+	atom.Applet:   true,
+	atom.Link:     true,
+	atom.Meta:     true,
+	atom.Base:     true,
+	atom.Svg:      true,
+	atom.Math:     true,
+	atom.Input:    true,
+	atom.Button:   true,
+	atom.Select:   true,
+	atom.Textarea: true,
+}
+
+// elements kept by HTML, any other element is replaced by its content
+var allowedElements = map[atom.Atom]bool{
+	atom.A:          true,
+	atom.Abbr:       true,
+	atom.Article:    true,
+	atom.Aside:      true,
+	atom.Audio:      true,
+	atom.B:          true,
+	atom.Blockquote: true,
+	atom.Br:         true,
+	atom.Caption:    true,
+	atom.Cite:       true,
+	atom.Code:       true,
+	atom.Col:        true,
+	atom.Colgroup:   true,
+	atom.Dd:         true,
+	atom.Del:        true,
+	atom.Details:    true,
+	atom.Dfn:        true,
+	atom.Div:        true,
+	atom.Dl:         true,
+	atom.Dt:         true,
+	atom.Em:         true,
+	atom.Figcaption: true,
+	atom.Figure:     true,
+	atom.Footer:     true,
+	atom.H1:         true,
+	atom.H2:         true,
+	atom.H3:         true,
+	atom.H4:         true,
+	atom.H5:         true,
+	atom.H6:         true,
+	atom.Header:     true,
+	atom.Hr:         true,
+	atom.I:          true,
+	atom.Img:        true,
+	atom.Ins:        true,
+	atom.Kbd:        true,
+	atom.Li:         true,
+	atom.Mark:       true,
+	atom.Ol:         true,
+	atom.P:          true,
+	atom.Picture:    true,
+	atom.Pre:        true,
+	atom.Q:          true,
+	atom.S:          true,
+	atom.Samp:       true,
+	atom.Section:    true,
+	atom.Small:      true,
+	atom.Source:     true,
+	atom.Span:       true,
+	atom.Strong:     true,
+	atom.Sub:        true,
+	atom.Summary:    true,
+	atom.Sup:        true,
+	atom.Table:      true,
+	atom.Tbody:      true,
+	atom.Td:         true,
+	atom.Tfoot:      true,
+	atom.Th:         true,
+	atom.Thead:      true,
+	atom.Time:       true,
+	atom.Tr:         true,
+	atom.Track:      true,
+	atom.U:          true,
+	atom.Ul:         true,
+	atom.Var:        true,
+	atom.Video:      true,
+}
+
+// formatting elements whose tags are removed from titles
+var inlineElements = map[atom.Atom]bool{
+	atom.A:      true,
+	atom.Abbr:   true,
+	atom.B:      true,
+	atom.Br:     true,
+	atom.Cite:   true,
+	atom.Code:   true,
+	atom.Del:    true,
+	atom.Em:     true,
+	atom.I:      true,
+	atom.Ins:    true,
+	atom.Kbd:    true,
+	atom.Mark:   true,
+	atom.Q:      true,
+	atom.S:      true,
+	atom.Small:  true,
+	atom.Span:   true,
+	atom.Strong: true,
+	atom.Sub:    true,
+	atom.Sup:    true,
+	atom.U:      true,
+}
+
+// attributes kept by HTML, any other attribute (style, class, id, on*, form actions, ...) is removed
+var allowedAttributes = map[string]bool{
+	"abbr":     true,
+	"alt":      true,
+	"cite":     true,
+	"colspan":  true,
+	"controls": true,
+	"datetime": true,
+	"dir":      true,
+	"headers":  true,
+	"height":   true,
+	"href":     true,
+	"kind":     true,
+	"label":    true,
+	"lang":     true,
+	"open":     true,
+	"poster":   true,
+	"reversed": true,
+	"rowspan":  true,
+	"scope":    true,
+	"sizes":    true,
+	"span":     true,
+	"src":      true,
+	"srclang":  true,
+	"srcset":   true,
+	"start":    true,
+	"title":    true,
+	"type":     true,
+	"width":    true,
+}
+
+// attributes holding a URL, resolved against the base URL
+var urlAttributes = map[string]bool{
+	"href":   true,
+	"src":    true,
+	"poster": true,
+	"cite":   true,
+}
+
+// URL schemes kept in URL attributes, URLs without a scheme are relative and always kept
+var allowedSchemes = map[string]bool{
+	"http":   true,
+	"https":  true,
+	"mailto": true,
+}
+
+// hosts that only serve tracking pixels or feed ads
+var trackingHosts = []string{
+	"feeds.feedburner.com",
+	"feedproxy.google.com",
+	"pixel.wp.com",
+	"stats.wordpress.com",
+	"www.google-analytics.com",
+	"pixel.quantserve.com",
+	"ad.doubleclick.net",
+}
+
+// elements rendered as separate paragraphs in the plain text rendering
+var paragraphElements = map[atom.Atom]bool{
+	atom.P:          true,
+	atom.H1:         true,
+	atom.H2:         true,
+	atom.H3:         true,
+	atom.H4:         true,
+	atom.H5:         true,
+	atom.H6:         true,
+	atom.Blockquote: true,
+	atom.Pre:        true,
+	atom.Ul:         true,
+	atom.Ol:         true,
+	atom.Table:      true,
+	atom.Figure:     true,
+	atom.Hr:         true,
+}
+
+// elements rendered on their own line in the plain text rendering
+var lineElements = map[atom.Atom]bool{
+	atom.Br:         true,
+	atom.Div:        true,
+	atom.Li:         true,
+	atom.Tr:         true,
+	atom.Section:    true,
+	atom.Article:    true,
+	atom.Figcaption: true,
+}
+
+// written around paragraphs by writeText, becomes a blank line in the plain text
+const paragraphBreak = "\n\x1e\n"
+
+// HTML returns the fragment with only the allowed elements and attributes, without
+// script URLs and tracking pixels, and with relative links resolved against baseURL
+// (left as is when empty)
+func HTML(fragment string, baseURL string) string {
+	if strings.TrimSpace(fragment) == "" {
+		return ""
+	}
+	base, err := url.Parse(baseURL)
+	if err != nil || !base.IsAbs() {
+		base = nil
+	}
+	// a container lets the top level nodes be removed and unwrapped like any other
+	container := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	for _, node := range parseFragment(fragment) {
+		container.AppendChild(node)
+	}
+	cleanChildren(container, base)
+	var buf bytes.Buffer
+	for node := container.FirstChild; node != nil; node = node.NextSibling {
+		html.Render(&buf, node)
+	}
+	return strings.TrimSpace(buf.String())
+}
+
+// Text returns a plain text rendering of an HTML fragment, one line per block element
+// and a blank line between paragraphs
+func Text(fragment string) string {
+	var buf strings.Builder
+	for _, node := range parseFragment(fragment) {
+		writeText(&buf, node)
+	}
+	lines := []string{}
+	blank := false
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if line == "\x1e" {
+			blank = len(lines) > 0
+			continue
+		}
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" {
+			continue
+		}
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Title returns a title as plain text on one line, decoding entities and dropping inline markup
+// such as <em> or <code>, any other tag is kept as text, e.g. "Understanding the <script> tag"
+func Title(title string) string {
+	if strings.Contains(title, "<") {
+		title = stripInlineTags(title)
+	}
+	return strings.Join(strings.Fields(html.UnescapeString(title)), " ")
+}
+
+// removes comments and the tags of inlineElements, the text between them is kept
+func stripInlineTags(s string) string {
+	var buf strings.Builder
+	for {
+		start := strings.IndexByte(s, '<')
+		if start == -1 {
+			buf.WriteString(s)
+			return buf.String()
+		}
+		buf.WriteString(s[:start])
+		s = s[start:]
+		if strings.HasPrefix(s, "<!--") {
+			end := strings.Index(s, "-->")
+			if end == -1 {
+				return buf.String()
+			}
+			s = s[end+len("-->"):]
+			continue
+		}
+		end := strings.IndexByte(s, '>')
+		if end == -1 {
+			buf.WriteString(s)
+			return buf.String()
+		}
+		name := tagName(strings.TrimPrefix(s[1:end], "/"))
+		if !inlineElements[atom.Lookup([]byte(name))] {
+			// not markup this function knows, kept as text
+			buf.WriteString("<")
+			s = s[1:]
+			continue
+		}
+		if name == "br" {
+			buf.WriteString(" ")
+		}
+		s = s[end+1:]
+	}
+}
+
+// returns the lower case name a tag's text starts with, empty when it does not start with
+// a letter, as in "a < b", or the name is not followed by whitespace, "/" or the tag's end
+func tagName(tag string) string {
+	end := 0
+	for end < len(tag) && (isASCIILetter(tag[end]) || end > 0 && tag[end] >= '0' && tag[end] <= '9') {
+		end++
+	}
+	if end == 0 || end < len(tag) && !strings.ContainsRune(" \t\n\r\f/", rune(tag[end])) {
+		return ""
+	}
+	return strings.ToLower(tag[:end])
+}
+
+func isASCIILetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// parses an HTML fragment as the content of a <body>
+func parseFragment(fragment string) []*html.Node {
+	context := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), context)
+	if err != nil {
+		// the parser only fails on read errors, which a strings.Reader does not have
+		return []*html.Node{{Type: html.TextNode, Data: fragment}}
+	}
+	return nodes
+}
+
+// cleans the children of a node in place, dropped elements, tracking pixels and comments
+// are removed and elements that are not allowed are replaced by their cleaned children
+func cleanChildren(node *html.Node, base *url.URL) {
+	for child := node.FirstChild; child != nil; {
+		next := child.NextSibling
+		switch {
+		case child.Type == html.TextNode:
+		case child.Type != html.ElementNode || droppedElements[child.DataAtom] || isTrackingPixel(child):
+			node.RemoveChild(child)
+		case !allowedElements[child.DataAtom]:
+			cleanChildren(child, base)
+			for grandchild := child.FirstChild; grandchild != nil; grandchild = child.FirstChild {
+				child.RemoveChild(grandchild)
+				node.InsertBefore(grandchild, child)
+			}
+			node.RemoveChild(child)
+		default:
+			cleanAttributes(child, base)
+			cleanChildren(child, base)
+		}
+		child = next
+	}
+}
+
+// keeps the allowed attributes of an element, with their URLs resolved against base
+func cleanAttributes(node *html.Node, base *url.URL) {
+	attrs := node.Attr[:0]
+	for _, attr := range node.Attr {
+		key := strings.ToLower(attr.Key)
+		if attr.Namespace != "" || !allowedAttributes[key] {
+			continue
+		}
+		if urlAttributes[key] {
+			value, ok := cleanURL(attr.Val, base)
+			if !ok {
+				continue
+			}
+			attr.Val = value
+		}
+		if key == "srcset" {
+			attr.Val = cleanSrcset(attr.Val, base)
+		}
+		attrs = append(attrs, attr)
+	}
+	node.Attr = attrs
+}
+
+// returns a URL attribute resolved against base, false for URLs with a scheme that is not allowed
+func cleanURL(value string, base *url.URL) (string, bool) {
+	// browsers ignore tabs and newlines anywhere in a URL and leading or trailing
+	// spaces and control characters, so "jav\tascript:" is a javascript: URL
+	value = strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' {
+			return -1
+		}
+		return r
+	}, value)
+	value = strings.TrimFunc(value, func(r rune) bool { return r <= ' ' })
+	if scheme, ok := urlScheme(value); ok && !allowedSchemes[scheme] {
+		return "", false
+	}
+	if base == nil || value == "" || strings.HasPrefix(value, "#") {
+		return value, true
+	}
+	ref, err := url.Parse(value)
+	if err != nil {
+		return value, true
+	}
+	return base.ResolveReference(ref).String(), true
+}
+
+// returns the lower case scheme of a URL, false for relative URLs
+func urlScheme(value string) (string, bool) {
+	for i, r := range value {
+		switch {
+		case r == ':':
+			return strings.ToLower(value[:i]), true
+		case r == '/' || r == '?' || r == '#':
+			return "", false
+		}
+	}
+	return "", false
+}
+
+// resolves the URLs of a srcset list, "url [descriptor], ..."
+func cleanSrcset(srcset string, base *url.URL) string {
+	candidates := []string{}
+	for _, candidate := range strings.Split(srcset, ",") {
+		fields := strings.Fields(candidate)
+		if len(fields) == 0 {
+			continue
+		}
+		value, ok := cleanURL(fields[0], base)
+		if !ok {
+			continue
+		}
+		fields[0] = value
+		candidates = append(candidates, strings.Join(fields, " "))
+	}
+	return strings.Join(candidates, ", ")
+}
+
+// reports whether an image is a tracking pixel: at most 1x1 or served by a tracking host
+func isTrackingPixel(node *html.Node) bool {
+	if node.DataAtom != atom.Img {
+		return false
+	}
+	width, height := "", ""
+	for _, attr := range node.Attr {
+		switch strings.ToLower(attr.Key) {
+		case "width":
+			width = strings.TrimSuffix(strings.TrimSpace(attr.Val), "px")
+		case "height":
+			height = strings.TrimSuffix(strings.TrimSpace(attr.Val), "px")
+		case "src":
+			src, err := url.Parse(strings.TrimSpace(attr.Val))
+			if err != nil {
+				continue
+			}
+			for _, host := range trackingHosts {
+				if strings.EqualFold(src.Hostname(), host) {
+					return true
+				}
+			}
+		}
+	}
+	tiny := func(size string) bool { return size == "0" || size == "1" }
+	return tiny(width) && tiny(height)
+}
+
+// appends the text of a node, block elements on their own lines
+func writeText(buf *strings.Builder, node *html.Node) {
+	switch node.Type {
+	case html.TextNode:
+		buf.WriteString(node.Data)
+		return
+	case html.CommentNode:
+		return
+	case html.ElementNode:
+		if droppedElements[node.DataAtom] {
+			return
+		}
+	}
+	separator := ""
+	if node.Type == html.ElementNode && paragraphElements[node.DataAtom] {
+		separator = paragraphBreak
+	} else if node.Type == html.ElementNode && lineElements[node.DataAtom] {
+		separator = "\n"
+	}
+	buf.WriteString(separator)
+	if node.DataAtom == atom.Li {
+		buf.WriteString("- ")
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		writeText(buf, child)
+	}
+	buf.WriteString(separator)
+}
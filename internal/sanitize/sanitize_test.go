@@ -0,0 +1,146 @@
+package sanitize
+
+import "testing"
+
+func TestHTML(t *testing.T) {
+	const base = "https://blog.example.com/posts/hello"
+	tests := []struct {
+		name     string
+		fragment string
+		want     string
+	}{
+		{
+			name:     "javascript URL",
+			fragment: `<a href="javascript:alert(1)">x</a>`,
+			want:     `<a>x</a>`,
+		},
+		{
+			name:     "javascript URL with mixed case and an encoded tab",
+			fragment: `<a href="JaVa&#x09;ScRiPt:alert(1)">x</a>`,
+			want:     `<a>x</a>`,
+		},
+		{
+			name:     "javascript URL with a leading control character",
+			fragment: `<a href=" &#1;javascript:alert(1)">x</a>`,
+			want:     `<a>x</a>`,
+		},
+		{
+			name:     "data URL",
+			fragment: `<img src="data:image/svg+xml;base64,PHN2Zz4=" alt="a">`,
+			want:     `<img alt="a"/>`,
+		},
+		{
+			name:     "mailto URL",
+			fragment: `<a href="mailto:me@example.com">mail</a>`,
+			want:     `<a href="mailto:me@example.com">mail</a>`,
+		},
+		{
+			name:     "event handlers and style",
+			fragment: `<p onclick="alert(1)" style="color:red" class="c" id="i">text</p>`,
+			want:     `<p>text</p>`,
+		},
+		{
+			name:     "scripts and forms",
+			fragment: `<script>alert(1)</script><form action="https://evil.example.com"><button formaction="javascript:x">b</button>kept</form>`,
+			want:     `kept`,
+		},
+		{
+			name:     "unknown elements are unwrapped",
+			fragment: `<font color="red">kept <b>bold</b></font><!-- comment -->`,
+			want:     `kept <b>bold</b>`,
+		},
+		{
+			name:     "tracking pixel by size",
+			fragment: `<p>text<img src="https://blog.example.com/p.gif" width="1" height="1"></p>`,
+			want:     `<p>text</p>`,
+		},
+		{
+			name:     "tracking pixel by host",
+			fragment: `<p>text<img src="https://pixel.wp.com/g.gif"></p>`,
+			want:     `<p>text</p>`,
+		},
+		{
+			name:     "relative URLs",
+			fragment: `<a href="/about">about</a> <a href="#top">top</a> <img src="img/a.png">`,
+			want:     `<a href="https://blog.example.com/about">about</a> <a href="#top">top</a> <img src="https://blog.example.com/posts/img/a.png"/>`,
+		},
+		{
+			name:     "srcset",
+			fragment: `<img src="a.png" srcset="a.png 1x, /b.png 2x, javascript:x 3x">`,
+			want:     `<img src="https://blog.example.com/posts/a.png" srcset="https://blog.example.com/posts/a.png 1x, https://blog.example.com/b.png 2x"/>`,
+		},
+		{
+			name:     "empty",
+			fragment: "  ",
+			want:     "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := HTML(tt.fragment, base)
+			if got != tt.want {
+				t.Errorf("HTML(%q)\n got %q\nwant %q", tt.fragment, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTitle(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{title: "Understanding the <script> tag in HTML", want: "Understanding the <script> tag in HTML"},
+		{title: "a < b > c", want: "a < b > c"},
+		{title: "a < b and c > d", want: "a < b and c > d"},
+		{title: "Using <em>generics</em> in Go &amp; Rust", want: "Using generics in Go & Rust"},
+		{title: "A &lt;b&gt; is bold", want: "A <b> is bold"},
+		{title: "line<br/>break", want: "line break"},
+		{title: "x <!-- hidden --> y", want: "x y"},
+		{title: "  spread\n over   lines ", want: "spread over lines"},
+		{title: "unterminated <em", want: "unterminated <em"},
+	}
+	for _, tt := range tests {
+		got := Title(tt.title)
+		if got != tt.want {
+			t.Errorf("Title(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		name     string
+		fragment string
+		want     string
+	}{
+		{
+			name:     "paragraphs",
+			fragment: `<p>First   paragraph</p><p>Second<br>line</p>`,
+			want:     "First paragraph\n\nSecond\nline",
+		},
+		{
+			name:     "list",
+			fragment: `<p>Intro</p><ul><li>one</li><li>two</li></ul>`,
+			want:     "Intro\n\n- one\n- two",
+		},
+		{
+			name:     "scripts and styles are dropped",
+			fragment: `<style>p{}</style>text<script>alert(1)</script>`,
+			want:     "text",
+		},
+		{
+			name:     "entities",
+			fragment: `Tom &amp; Jerry &lt;3`,
+			want:     "Tom & Jerry <3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Text(tt.fragment)
+			if got != tt.want {
+				t.Errorf("Text(%q)\n got %q\nwant %q", tt.fragment, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package scheduler
+
+import (
+	"sort"
+	"time"
+)
+
+// defaults used for the Bounds left at zero
+const (
+	DefaultMinInterval = 10 * time.Minute
+	DefaultMaxInterval = 24 * time.Hour
+	// interval used when a feed gives no hints and has too few dated posts
+	DefaultInterval = time.Hour
+)
+
+// Bounds clamp the computed refresh interval of every feed
+type Bounds struct {
+	Min time.Duration
+	Max time.Duration
+}
+
+// Hints are everything known about how often a feed changes, zero values when unknown
+type Hints struct {
+	// RSS <ttl>
+	TTL time.Duration
+	// sy:updatePeriod / sy:updateFrequency
+	UpdateInterval time.Duration
+	// Cache-Control max-age of the last response
+	MaxAge time.Duration
+	// average time between the feed's recent posts
+	PostingInterval time.Duration
+	// hours (GMT) and days the feed asks not to be fetched in
+	SkipHours []int
+	SkipDays  []time.Weekday
+	// updates are pushed by a WebSub hub, polling is only a fallback
+	Pushed bool
+}
+
+// Interval returns how long to wait before fetching a feed again
+// the feed is polled twice per observed posting interval, but never more often than
+// its TTL, syndication period or Cache-Control ask for, and always within bounds
+// feeds with pushed updates are polled as rarely as the bounds allow
+func Interval(hints Hints, bounds Bounds) time.Duration {
+	bounds = bounds.withDefaults()
+	if hints.Pushed {
+		return bounds.Max
+	}
+	interval := DefaultInterval
+	if hints.PostingInterval > 0 {
+		interval = hints.PostingInterval / 2
+	}
+	for _, floor := range []time.Duration{hints.TTL, hints.UpdateInterval, hints.MaxAge} {
+		if floor > interval {
+			interval = floor
+		}
+	}
+	if interval < bounds.Min {
+		interval = bounds.Min
+	}
+	if interval > bounds.Max {
+		interval = bounds.Max
+	}
+	return interval
+}
+
+// NextFetch returns when to fetch a feed again, moved out of the skipped hours and days
+// the skipped hours are GMT, the result is in the location of now like every other time
+// stored in the feeds table, which has no time zone
+func NextFetch(now time.Time, interval time.Duration, hints Hints) time.Time {
+	next := now.Add(interval).UTC()
+	// a week of hours is enough to leave any combination of skipped hours and days
+	for i := 0; i < 7*24 && skipped(next, hints); i++ {
+		next = next.Truncate(time.Hour).Add(time.Hour)
+	}
+	return next.In(now.Location())
+}
+
+// reports whether t falls in a skipped hour or day
+func skipped(t time.Time, hints Hints) bool {
+	for _, hour := range hints.SkipHours {
+		if t.Hour() == hour {
+			return true
+		}
+	}
+	for _, day := range hints.SkipDays {
+		if t.Weekday() == day {
+			return true
+		}
+	}
+	return false
+}
+
+// PostingInterval returns the average time between the most recent posts, 0 with fewer than two
+func PostingInterval(published []time.Time) time.Duration {
+	const recentPosts = 20
+	if len(published) < 2 {
+		return 0
+	}
+	sorted := make([]time.Time, len(published))
+	copy(sorted, published)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].After(sorted[j]) })
+	if len(sorted) > recentPosts {
+		sorted = sorted[:recentPosts]
+	}
+	span := sorted[0].Sub(sorted[len(sorted)-1])
+	return span / time.Duration(len(sorted)-1)
+}
+
+func (b Bounds) withDefaults() Bounds {
+	if b.Min <= 0 {
+		b.Min = DefaultMinInterval
+	}
+	if b.Max <= 0 {
+		b.Max = DefaultMaxInterval
+	}
+	if b.Max < b.Min {
+		b.Max = b.Min
+	}
+	return b
+}
@@ -0,0 +1,52 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextFetch(t *testing.T) {
+	// five hours west of GMT, like a machine set to New York in winter
+	newYork := time.FixedZone("EST", -5*60*60)
+	// 2024-03-05 10:30 EST is 15:30 GMT, a Tuesday
+	now := time.Date(2024, 3, 5, 10, 30, 0, 0, newYork)
+
+	tests := []struct {
+		name     string
+		interval time.Duration
+		hints    Hints
+		want     time.Time
+	}{
+		{
+			name:     "no skipped hours",
+			interval: time.Hour,
+			want:     time.Date(2024, 3, 5, 11, 30, 0, 0, newYork),
+		},
+		{
+			// 16:30 GMT falls in the skipped hours 16 and 17, the next fetch is at 18:00 GMT
+			name:     "skipped hours are GMT",
+			interval: time.Hour,
+			hints:    Hints{SkipHours: []int{16, 17}},
+			want:     time.Date(2024, 3, 5, 13, 0, 0, 0, newYork),
+		},
+		{
+			// 20:30 EST on Tuesday is already Wednesday in GMT
+			name:     "skipped days are GMT",
+			interval: 10 * time.Hour,
+			hints:    Hints{SkipDays: []time.Weekday{time.Wednesday}},
+			want:     time.Date(2024, 3, 6, 19, 0, 0, 0, newYork),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NextFetch(now, tt.interval, tt.hints)
+			if !got.Equal(tt.want) {
+				t.Errorf("NextFetch = %v, want %v", got, tt.want)
+			}
+			// the feeds table stores times without their zone, so the wall clock must be now's
+			if got.Location() != now.Location() {
+				t.Errorf("NextFetch location = %v, want %v", got.Location(), now.Location())
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package websub
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lease asked of the hub, hubs are free to grant a different one
+const DefaultLease = 10 * 24 * time.Hour
+
+// largest content distribution request that is read, larger ones are rejected
+const DefaultMaxBodySize = 10 << 20
+
+// ErrUnknownSubscription is returned by a Store for a callback that matches no subscription
+var ErrUnknownSubscription = errors.New("unknown subscription")
+
+// Subscription is what the callback handler needs to know about a subscription
+type Subscription struct {
+	Topic  string
+	Secret string
+}
+
+// Store looks up and updates the subscriptions the callback handler receives requests for,
+// id is the last path segment of the callback URL
+type Store interface {
+	// returns ErrUnknownSubscription when there is no subscription with the id
+	Subscription(ctx context.Context, id string) (Subscription, error)
+	// the hub verified the subscription, it expires after lease
+	Verified(ctx context.Context, id string, lease time.Duration) error
+	// the hub refused the subscription or cancelled it
+	Denied(ctx context.Context, id string, reason string) error
+	// new content of the topic, only called once the signature was validated
+	Deliver(ctx context.Context, id string, contentType string, body []byte) error
+}
+
+// Client sends subscription requests to hubs
+type Client struct {
+	HTTPClient *http.Client
+	// base URL the callback Handler is reachable at, the subscription id is appended to it
+	CallbackURL string
+	// lease asked of the hub, DefaultLease when 0
+	Lease time.Duration
+}
+
+// CallbackFor returns the callback URL of a subscription
+func (c *Client) CallbackFor(id string) string {
+	return strings.TrimSuffix(c.CallbackURL, "/") + "/" + url.PathEscape(id)
+}
+
+// Subscribe asks the hub to push updates of the topic to the subscription's callback,
+// the hub confirms later with a verification request to the Handler
+func (c *Client) Subscribe(ctx context.Context, hubURL, topic, id, secret string) error {
+	lease := c.Lease
+	if lease <= 0 {
+		lease = DefaultLease
+	}
+	form := url.Values{}
+	form.Set("hub.mode", "subscribe")
+	form.Set("hub.topic", topic)
+	form.Set("hub.callback", c.CallbackFor(id))
+	form.Set("hub.lease_seconds", strconv.Itoa(int(lease/time.Second)))
+	if secret != "" {
+		form.Set("hub.secret", secret)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", hubURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", "Gator")
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	// hubs answer 202 Accepted, some verify synchronously and answer 204
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 200))
+		return fmt.Errorf("hub %s refused the subscription to %s: %s %q", hubURL, topic, res.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// NewSecret returns a random secret for the HMAC signatures of a subscription
+func NewSecret() (string, error) {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// Handler serves the callback URLs: intent verification (GET) and content distribution (POST)
+type Handler struct {
+	Store Store
+	// DefaultMaxBodySize when 0
+	MaxBodySize int64
+	// called with errors that cannot be reported to the hub, may be nil
+	ErrorLog func(format string, args ...any)
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id, err := url.PathUnescape(path.Base(r.URL.Path))
+	if err != nil || id == "" || id == "/" || id == "." {
+		http.NotFound(w, r)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		h.verify(w, r, id)
+	case http.MethodPost:
+		h.deliver(w, r, id)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// answers the hub's verification of intent, the challenge is echoed only for
+// subscriptions this subscriber actually asked for
+func (h *Handler) verify(w http.ResponseWriter, r *http.Request, id string) {
+	ctx := r.Context()
+	query := r.URL.Query()
+	mode := query.Get("hub.mode")
+	sub, err := h.Store.Subscription(ctx, id)
+	if errors.Is(err, ErrUnknownSubscription) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		h.logf("websub: looking up subscription %s: %v", id, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if query.Get("hub.topic") != sub.Topic {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch mode {
+	case "subscribe":
+		leaseSeconds, err := strconv.Atoi(query.Get("hub.lease_seconds"))
+		if err != nil || leaseSeconds <= 0 {
+			http.Error(w, "missing hub.lease_seconds", http.StatusBadRequest)
+			return
+		}
+		err = h.Store.Verified(ctx, id, time.Duration(leaseSeconds)*time.Second)
+		if err != nil {
+			h.logf("websub: confirming subscription %s: %v", id, err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, query.Get("hub.challenge"))
+	case "denied":
+		err = h.Store.Denied(ctx, id, query.Get("hub.reason"))
+		if err != nil {
+			h.logf("websub: removing denied subscription %s: %v", id, err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	default:
+		// unsubscribing is never requested by this subscriber, the subscription is still wanted
+		http.NotFound(w, r)
+	}
+}
+
+// receives pushed content, content with a missing or invalid signature is acknowledged but dropped
+func (h *Handler) deliver(w http.ResponseWriter, r *http.Request, id string) {
+	ctx := r.Context()
+	sub, err := h.Store.Subscription(ctx, id)
+	if errors.Is(err, ErrUnknownSubscription) {
+		// 410 tells the hub to drop a subscription that no longer exists here
+		http.Error(w, "unknown subscription", http.StatusGone)
+		return
+	}
+	if err != nil {
+		h.logf("websub: looking up subscription %s: %v", id, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	maxBodySize := h.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = DefaultMaxBodySize
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+	if err != nil {
+		http.Error(w, "could not read body", http.StatusBadRequest)
+		return
+	}
+	if int64(len(body)) > maxBodySize {
+		http.Error(w, "body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if sub.Secret != "" && !ValidSignature(sub.Secret, r.Header.Get("X-Hub-Signature"), body) {
+		h.logf("websub: dropped content for subscription %s with an invalid signature", id)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	err = h.Store.Deliver(ctx, id, r.Header.Get("Content-Type"), body)
+	if err != nil {
+		// a 5xx makes the hub retry the delivery later
+		h.logf("websub: saving content for subscription %s: %v", id, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ValidSignature reports whether an X-Hub-Signature header ("method=hexdigest") is the HMAC
+// of the body with the secret, sha1, sha256, sha384 and sha512 are accepted
+func ValidSignature(secret, signature string, body []byte) bool {
+	method, digest, found := strings.Cut(strings.TrimSpace(signature), "=")
+	if !found {
+		return false
+	}
+	var newHash func() hash.Hash
+	switch strings.ToLower(method) {
+	case "sha1":
+		newHash = sha1.New
+	case "sha256":
+		newHash = sha256.New
+	case "sha384":
+		newHash = sha512.New384
+	case "sha512":
+		newHash = sha512.New
+	default:
+		return false
+	}
+	expected, err := hex.DecodeString(digest)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+func (h *Handler) logf(format string, args ...any) {
+	if h.ErrorLog != nil {
+		h.ErrorLog(format, args...)
+	}
+}
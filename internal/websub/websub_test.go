@@ -0,0 +1,236 @@
+package websub
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memStore keeps subscriptions in memory and records what the handler reports
+type memStore struct {
+	mu        sync.Mutex
+	subs      map[string]Subscription
+	leases    map[string]time.Duration
+	denied    map[string]string
+	delivered map[string][]string
+}
+
+func newMemStore() *memStore {
+	return &memStore{
+		subs:      map[string]Subscription{},
+		leases:    map[string]time.Duration{},
+		denied:    map[string]string{},
+		delivered: map[string][]string{},
+	}
+}
+
+func (m *memStore) Subscription(ctx context.Context, id string) (Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sub, ok := m.subs[id]
+	if !ok {
+		return Subscription{}, ErrUnknownSubscription
+	}
+	return sub, nil
+}
+
+func (m *memStore) Verified(ctx context.Context, id string, lease time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.leases[id] = lease
+	return nil
+}
+
+func (m *memStore) Denied(ctx context.Context, id string, reason string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.denied[id] = reason
+	return nil
+}
+
+func (m *memStore) Deliver(ctx context.Context, id string, contentType string, body []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.delivered[id] = append(m.delivered[id], string(body))
+	return nil
+}
+
+func (m *memStore) deliveries(id string) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.delivered[id]
+}
+
+func sign(secret string, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// newCallback serves a Handler for the store, its URL is the callback base URL
+func newCallback(t *testing.T, store *memStore) *httptest.Server {
+	t.Helper()
+	handler := &Handler{Store: store, ErrorLog: t.Logf}
+	server := httptest.NewServer(http.StripPrefix("/websub", handler))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSubscribeIsVerifiedByTheHub(t *testing.T) {
+	store := newMemStore()
+	store.subs["sub1"] = Subscription{Topic: "https://example.com/feed", Secret: "s3cret"}
+	callback := newCallback(t, store)
+
+	// the hub verifies the intent synchronously, like hubs answering 204
+	hubErrors := make(chan string, 1)
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := r.ParseForm()
+		if err != nil {
+			hubErrors <- err.Error()
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if r.Form.Get("hub.mode") != "subscribe" || r.Form.Get("hub.secret") != "s3cret" {
+			hubErrors <- "unexpected subscription request: " + r.Form.Encode()
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		query := url.Values{}
+		query.Set("hub.mode", "subscribe")
+		query.Set("hub.topic", r.Form.Get("hub.topic"))
+		query.Set("hub.challenge", "challenge-123")
+		query.Set("hub.lease_seconds", r.Form.Get("hub.lease_seconds"))
+		res, err := http.Get(r.Form.Get("hub.callback") + "?" + query.Encode())
+		if err != nil {
+			hubErrors <- err.Error()
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		defer res.Body.Close()
+		body, _ := io.ReadAll(res.Body)
+		if res.StatusCode != http.StatusOK || string(body) != "challenge-123" {
+			hubErrors <- "challenge not echoed: " + res.Status + " " + string(body)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer hub.Close()
+
+	client := &Client{CallbackURL: callback.URL + "/websub/", Lease: time.Hour}
+	err := client.Subscribe(context.Background(), hub.URL, "https://example.com/feed", "sub1", "s3cret")
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	select {
+	case msg := <-hubErrors:
+		t.Fatal(msg)
+	default:
+	}
+	if store.leases["sub1"] != time.Hour {
+		t.Errorf("lease = %v, want %v", store.leases["sub1"], time.Hour)
+	}
+}
+
+func TestSubscribeRefused(t *testing.T) {
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "topic not allowed", http.StatusForbidden)
+	}))
+	defer hub.Close()
+
+	client := &Client{CallbackURL: "https://reader.example.com/websub"}
+	err := client.Subscribe(context.Background(), hub.URL, "https://example.com/feed", "sub1", "")
+	if err == nil {
+		t.Fatal("Subscribe succeeded, want an error for a 403")
+	}
+}
+
+func TestVerifyTopicMismatch(t *testing.T) {
+	store := newMemStore()
+	store.subs["sub1"] = Subscription{Topic: "https://example.com/feed"}
+	callback := newCallback(t, store)
+
+	query := url.Values{}
+	query.Set("hub.mode", "subscribe")
+	query.Set("hub.topic", "https://attacker.example.com/feed")
+	query.Set("hub.challenge", "challenge-123")
+	query.Set("hub.lease_seconds", "3600")
+	res, err := http.Get(callback.URL + "/websub/sub1?" + query.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", res.StatusCode, http.StatusNotFound)
+	}
+	if strings.Contains(string(body), "challenge-123") {
+		t.Errorf("challenge echoed for a topic that was not subscribed to")
+	}
+	if _, ok := store.leases["sub1"]; ok {
+		t.Errorf("subscription verified for the wrong topic")
+	}
+}
+
+func TestDeliverSignatures(t *testing.T) {
+	const secret = "s3cret"
+	const content = "<feed></feed>"
+	tests := []struct {
+		name      string
+		signature string
+		saved     bool
+	}{
+		{name: "valid", signature: sign(secret, content), saved: true},
+		{name: "invalid", signature: sign("other secret", content), saved: false},
+		{name: "missing", signature: "", saved: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemStore()
+			store.subs["sub1"] = Subscription{Topic: "https://example.com/feed", Secret: secret}
+			callback := newCallback(t, store)
+
+			req, err := http.NewRequest("POST", callback.URL+"/websub/sub1", strings.NewReader(content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/atom+xml")
+			if tt.signature != "" {
+				req.Header.Set("X-Hub-Signature", tt.signature)
+			}
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+			// dropped content is still acknowledged, so the hub does not retry it
+			if res.StatusCode != http.StatusAccepted {
+				t.Errorf("status = %d, want %d", res.StatusCode, http.StatusAccepted)
+			}
+			saved := len(store.deliveries("sub1")) > 0
+			if saved != tt.saved {
+				t.Errorf("content saved = %v, want %v", saved, tt.saved)
+			}
+		})
+	}
+}
+
+func TestDeliverUnknownSubscription(t *testing.T) {
+	callback := newCallback(t, newMemStore())
+
+	res, err := http.Post(callback.URL+"/websub/gone", "application/atom+xml", strings.NewReader("<feed></feed>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusGone {
+		t.Errorf("status = %d, want %d", res.StatusCode, http.StatusGone)
+	}
+}
@@ -3,30 +3,65 @@ package main
 import (
 	"GoBlogAggregator/internal/config"
 	"GoBlogAggregator/internal/database"
+	"GoBlogAggregator/internal/fetcher"
+	"GoBlogAggregator/internal/parser"
+	"GoBlogAggregator/internal/sanitize"
+	"GoBlogAggregator/internal/scheduler"
+	"GoBlogAggregator/internal/websub"
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
-	"encoding/xml"
+	"errors"
 	"fmt"
-	"html"
-	"io"
 	"log"
+	"mime"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"path"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/lib/pq"
 	_ "github.com/lib/pq"
 )
 
 type Config = config.Config
 
+// how long a claimed feed stays reserved for one aggregator process,
+// leases of a crashed process expire after this and the feed is claimed again
+const feedLeaseDuration = 10 * time.Minute
+
+// longest a claimed feed waits for the rate limit of its host, well under feedLeaseDuration,
+// a feed that would wait longer is released and postponed instead
+const feedRateLimitWait = feedLeaseDuration / 5
+
+// a failing feed is retried after feedBackoffBase, doubling with every
+// consecutive failure up to feedBackoffMax
+const (
+	feedBackoffBase = time.Minute
+	feedBackoffMax  = 24 * time.Hour
+)
+
+// consecutive failures after which a feed is disabled when the config does not set max_feed_failures
+const defaultMaxFeedFailures = 20
+
+// a WebSub subscription the hub did not confirm is requested again after this
+const websubRetryInterval = time.Hour
+
 type state struct {
-	config *Config
-	db     *database.Queries
+	config        *Config
+	db            *database.Queries
+	fetcher       *fetcher.Client
+	refreshBounds scheduler.Bounds
+	// nil when WebSub is not configured
+	websub *websub.Client
 }
 
 type command struct {
@@ -38,22 +73,6 @@ type commands struct {
 	handlers map[string]func(*state, command) error
 }
 
-type RSSFeed struct {
-	Channel struct {
-		Title       string    `xml:"title"`
-		Link        string    `xml:"link"`
-		Description string    `xml:"description"`
-		Item        []RSSItem `xml:"item"`
-	} `xml:"channel"`
-}
-
-type RSSItem struct {
-	Title       string `xml:"title"`
-	Link        string `xml:"link"`
-	Description string `xml:"description"`
-	PubDate     string `xml:"pubDate"`
-}
-
 // registers a new handler function for a command name
 func (c *commands) registerHandler(name string, f func(*state, command) error) {
 	c.handlers[name] = f
@@ -153,6 +172,7 @@ func handlerUsers(s *state, cmd command) error {
 }
 
 // prints blog feed title for every given time between requests
+// takes an optional concurrency parameter, the number of feeds fetched in parallel each tick
 func handlerAgg(s *state, cmd command) error {
 	if len(cmd.args) < 1 {
 		return fmt.Errorf("No time between requests given")
@@ -162,29 +182,80 @@ func handlerAgg(s *state, cmd command) error {
 	if err != nil {
 		return fmt.Errorf("Invalid time.Duration value: %v\n%v", cmd.args[0], err)
 	}
+	var concurrency int = 1
+	if len(cmd.args) > 1 {
+		concurrency, err = strconv.Atoi(cmd.args[1])
+		if err != nil || concurrency < 1 {
+			return fmt.Errorf("concurrency must be a positive integer: %s", cmd.args[1])
+		}
+	}
+	workerID := uuid.New()
+	if s.websub != nil {
+		err = startWebsubListener(*s)
+		if err != nil {
+			return err
+		}
+	}
 	ticker := time.NewTicker(time_between_reqs)
+	// errors are logged and retried on the next tick, a database hiccup must not stop the aggregator
 	for ; ; <-ticker.C {
-		err := scrapeFeeds(context.Background(), *s)
+		err := scrapeFeeds(context.Background(), *s, concurrency, workerID)
 		if err != nil {
-			return err
+			log.Printf("Error scraping feeds: %v", err)
+		}
+		if s.websub != nil {
+			err = renewWebsubSubscriptions(context.Background(), *s)
+			if err != nil {
+				log.Printf("Error renewing WebSub subscriptions: %v", err)
+			}
 		}
 	}
 }
 
 // Get current user from the database, and make a new feed row
+// the feed is fetched first, so only URLs of working feeds are saved
 // args{
-// name: name of feed
-// url: url of feed }
+// name: name of feed, optional, defaults to the feed's title
+// url: url of feed, or of a website linking its feed }
 func handlerAddFeed(s *state, cmd command, user database.User) error {
-	if len(cmd.args) < 2 {
-		return fmt.Errorf("must provide feed name and url")
+	if len(cmd.args) < 1 {
+		return fmt.Errorf("must provide feed url, optionally preceded by a name")
+	}
+	var feedName string
+	var feedUrl string = cmd.args[0]
+	if len(cmd.args) > 1 {
+		feedName = cmd.args[0]
+		feedUrl = cmd.args[1]
 	}
-	var feedName string = cmd.args[0]
-	var feedUrl string = cmd.args[1]
 
-	_, err := url.Parse(feedUrl)
+	err := validateFeedURL(feedUrl)
 	if err != nil {
-		return fmt.Errorf("invalid URL provided: %v", err)
+		return err
+	}
+	// test fetch, rejecting anything agg could not read
+	result, err := s.fetcher.FetchFeed(context.Background(), feedUrl, "", "")
+	var notAFeedErr *fetcher.NotAFeedError
+	if errors.As(err, &notAFeedErr) {
+		// not a feed, it may be the website of one, discovery only runs then
+		// so a feed URL is downloaded once
+		feedUrl, err = discoverFeedURL(context.Background(), s, feedUrl)
+		if err != nil {
+			return err
+		}
+		result, err = s.fetcher.FetchFeed(context.Background(), feedUrl, "", "")
+	}
+	if err != nil {
+		return fmt.Errorf("%s is not a working feed: %v", feedUrl, err)
+	}
+	if result.PermanentURL != "" {
+		fmt.Printf("Feed moved permanently, saving %s instead\n", result.PermanentURL)
+		feedUrl = result.PermanentURL
+	}
+	if feedName == "" && result.Feed != nil {
+		feedName = strings.TrimSpace(result.Feed.Title)
+	}
+	if feedName == "" {
+		return fmt.Errorf("%s has no title, provide a feed name", feedUrl)
 	}
 
 	// add new feed row
@@ -237,6 +308,77 @@ func handlerFeeds(s *state, cmd command) error {
 	return nil
 }
 
+// prints the fetch health of every feed to find dead or quiet subscriptions
+func handlerFeedHealth(s *state, cmd command) error {
+	feeds, err := s.db.GetFeeds(context.Background())
+	if err != nil {
+		return err
+	}
+	postStats, err := s.db.GetFeedPostStats(context.Background())
+	if err != nil {
+		return err
+	}
+	statsByFeed := make(map[uuid.UUID]database.GetFeedPostStatsRow)
+	for _, stats := range postStats {
+		statsByFeed[stats.FeedID.UUID] = stats
+	}
+
+	now := time.Now()
+	for _, feed := range feeds {
+		fmt.Printf("* %s (%s)\n", feed.Name.String, feed.Url.String)
+		if feed.LastSuccessAt.Valid {
+			fmt.Printf("    last successful fetch: %s\n", feed.LastSuccessAt.Time.Format(time.RFC1123))
+		} else {
+			fmt.Printf("    last successful fetch: never\n")
+		}
+		if feed.LastError.Valid {
+			fmt.Printf("    last error (%s): %s\n", feed.LastErrorKind.String, feed.LastError.String)
+		}
+		if feed.LastStatusCode.Valid {
+			fmt.Printf("    http status: %d\n", feed.LastStatusCode.Int32)
+		}
+		fmt.Printf("    consecutive failures: %d\n", feed.ConsecutiveFailures)
+		if feed.DisabledAt.Valid {
+			fmt.Printf("    disabled since: %s\n", feed.DisabledAt.Time.Format(time.RFC1123))
+		} else if feed.NextFetchAt.Valid {
+			fmt.Printf("    next fetch: %s\n", feed.NextFetchAt.Time.Format(time.RFC1123))
+		}
+
+		stats, ok := statsByFeed[feed.ID]
+		if !ok {
+			fmt.Printf("    no posts\n")
+			continue
+		}
+		// average over the time since the oldest post, at least one week
+		weeks := now.Sub(stats.OldestPostAt).Hours() / (24 * 7)
+		if weeks < 1 {
+			weeks = 1
+		}
+		fmt.Printf("    items per week: %.1f\n", float64(stats.PostCount)/weeks)
+		fmt.Printf("    days since newest post: %d\n", int(now.Sub(stats.NewestPostAt).Hours()/24))
+	}
+
+	return nil
+}
+
+// re-enable a feed that was disabled after too many failures, takes the feed URL
+func handlerEnableFeed(s *state, cmd command) error {
+	if len(cmd.args) < 1 {
+		return fmt.Errorf("no feed URL given")
+	}
+	feedURL := cmd.args[0]
+
+	feed, err := s.db.EnableFeed(context.Background(), sql.NullString{String: feedURL, Valid: true})
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("no feed with URL %s", feedURL)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s enabled\n", feed.Name.String)
+	return nil
+}
+
 // Takes a single URL arguement. Create a feed_follows entry for the current user. Prints the user name and feed name
 func handlerFollow(s *state, cmd command, user database.User) error {
 	if len(cmd.args) < 1 {
@@ -250,6 +392,17 @@ func handlerFollow(s *state, cmd command, user database.User) error {
 
 	//Create the feed_follows entry
 	feed, err := s.db.GetFeedByURL(context.Background(), sql.NullString{String: feedURL, Valid: true})
+	if err == sql.ErrNoRows {
+		// not a known feed, it may be the website of one
+		feedURL, err = discoverFeedURL(context.Background(), s, feedURL)
+		if err != nil {
+			return err
+		}
+		feed, err = s.db.GetFeedByURL(context.Background(), sql.NullString{String: feedURL, Valid: true})
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("no feed with URL %s, add it with addfeed first", feedURL)
+		}
+	}
 	if err != nil {
 		return err
 	}
@@ -330,12 +483,152 @@ func handlerBrowse(s *state, cmd command, user database.User) error {
 	if err != nil {
 		return err
 	}
-	//print to the command line
-	fmt.Printf("%+v\n", posts)
+	//print to the command line, with the enclosures download takes
+	for _, post := range posts {
+		fmt.Printf("%+v\n", post)
+		if post.HasContent {
+			fmt.Printf("    full article: read %s\n", post.ID)
+		}
+		enclosures, err := s.db.GetEnclosuresForPost(context.Background(), post.ID)
+		if err != nil {
+			return err
+		}
+		for i, enclosure := range enclosures {
+			fmt.Printf("    enclosure %d: %s\n", i+1, describeEnclosure(enclosure))
+		}
+	}
 
 	return nil
 }
 
+// print a post with its full content, falling back to the description when the feed has none
+// args: post id as printed by browse
+func handlerRead(s *state, cmd command) error {
+	if len(cmd.args) < 1 {
+		return fmt.Errorf("no post id given")
+	}
+	postID, err := uuid.Parse(cmd.args[0])
+	if err != nil {
+		return fmt.Errorf("invalid post id %s: %v", cmd.args[0], err)
+	}
+	post, err := s.db.GetPost(context.Background(), postID)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("no post with id %s", postID)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println(post.Title.String)
+	fmt.Println(post.Url.String)
+	if post.PublishedAt.Valid {
+		fmt.Println(post.PublishedAt.Time.Format(time.RFC1123))
+	}
+	fmt.Println()
+	// posts saved before the sanitizer only have the HTML
+	switch {
+	case post.PlainText.Valid:
+		fmt.Println(post.PlainText.String)
+	case post.Content.Valid:
+		fmt.Println(sanitize.Text(post.Content.String))
+	default:
+		fmt.Println(sanitize.Text(post.Description.String))
+	}
+	return nil
+}
+
+// returns a one line description of an enclosure for browse
+func describeEnclosure(enclosure database.PostEnclosure) string {
+	details := []string{}
+	if enclosure.MimeType.Valid {
+		details = append(details, enclosure.MimeType.String)
+	}
+	if enclosure.Length.Valid {
+		details = append(details, fmt.Sprintf("%.1f MB", float64(enclosure.Length.Int64)/(1<<20)))
+	}
+	if enclosure.DurationSeconds.Valid {
+		details = append(details, (time.Duration(enclosure.DurationSeconds.Int32) * time.Second).String())
+	}
+	if enclosure.Season.Valid {
+		details = append(details, fmt.Sprintf("season %d", enclosure.Season.Int32))
+	}
+	if enclosure.Episode.Valid {
+		details = append(details, fmt.Sprintf("episode %d", enclosure.Episode.Int32))
+	}
+	description := enclosure.Url
+	if len(details) > 0 {
+		description += " (" + strings.Join(details, ", ") + ")"
+	}
+	if enclosure.ThumbnailUrl.Valid {
+		description += " thumbnail: " + enclosure.ThumbnailUrl.String
+	}
+	return description
+}
+
+// download an enclosure of a post to the download directory, an interrupted download is resumed
+// args: post id as printed by browse, optional enclosure number (defaults to 1)
+func handlerDownload(s *state, cmd command) error {
+	if len(cmd.args) < 1 {
+		return fmt.Errorf("no post id given")
+	}
+	postID, err := uuid.Parse(cmd.args[0])
+	if err != nil {
+		return fmt.Errorf("invalid post id %s: %v", cmd.args[0], err)
+	}
+	number := 1
+	if len(cmd.args) > 1 {
+		number, err = strconv.Atoi(cmd.args[1])
+		if err != nil || number < 1 {
+			return fmt.Errorf("enclosure number must be a positive integer: %s", cmd.args[1])
+		}
+	}
+	enclosures, err := s.db.GetEnclosuresForPost(context.Background(), postID)
+	if err != nil {
+		return err
+	}
+	if len(enclosures) == 0 {
+		return fmt.Errorf("post %s has no enclosures", postID)
+	}
+	if number > len(enclosures) {
+		return fmt.Errorf("post %s has only %d enclosures", postID, len(enclosures))
+	}
+	enclosure := enclosures[number-1]
+
+	downloadDir, err := downloadDirFromConfig(*s.config)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(downloadDir, 0755)
+	if err != nil {
+		return err
+	}
+	dest := filepath.Join(downloadDir, enclosureFileName(enclosure))
+	fmt.Printf("Downloading %s to %s\n", enclosure.Url, dest)
+	size, err := s.fetcher.Download(context.Background(), enclosure.Url, dest)
+	if err != nil {
+		return fmt.Errorf("download failed after %d bytes, run download again to resume: %v", size, err)
+	}
+	fmt.Printf("Saved %d bytes to %s\n", size, dest)
+	return nil
+}
+
+// returns the local file name of an enclosure, prefixed with the post id as podcasts
+// often reuse names like episode.mp3
+func enclosureFileName(enclosure database.PostEnclosure) string {
+	name := ""
+	enclosureURL, err := url.Parse(enclosure.Url)
+	if err == nil {
+		name = path.Base(enclosureURL.Path)
+	}
+	if name == "" || name == "." || name == "/" {
+		name = "enclosure"
+		extensions, err := mime.ExtensionsByType(enclosure.MimeType.String)
+		if err == nil && len(extensions) > 0 {
+			name += extensions[0]
+		}
+	}
+	return enclosure.PostID.String()[:8] + "-" + name
+}
+
 // middleware function to trim user parameter off the function signature
 func middlewareLoggedIn(handler func(s *state, cmd command, user database.User) error) func(*state, command) error {
 	return func(s *state, cmd command) error {
@@ -348,90 +641,675 @@ func middlewareLoggedIn(handler func(s *state, cmd command, user database.User)
 	}
 }
 
-// fetch a feed from the given URL, return an RSSFeed struct
-func fetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
+// rejects URLs that cannot be fetched over http(s)
+func validateFeedURL(rawURL string) error {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL provided: %v", err)
+	}
+	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+		return fmt.Errorf("invalid URL provided: %s, only http and https URLs are supported", rawURL)
+	}
+	if parsedURL.Host == "" {
+		return fmt.Errorf("invalid URL provided: %s has no host", rawURL)
+	}
+	return nil
+}
+
+// returns the feed a URL leads to, the URL itself when it is a feed or the single feed its website links,
+// several candidates are listed for the user to pick one
+func discoverFeedURL(ctx context.Context, s *state, pageURL string) (string, error) {
+	candidates, err := s.fetcher.Discover(ctx, pageURL)
+	if err != nil {
+		return "", fmt.Errorf("could not find a feed at %s: %v", pageURL, err)
+	}
+	switch len(candidates) {
+	case 0:
+		return "", fmt.Errorf("no feed found at %s", pageURL)
+	case 1:
+		if candidates[0].URL != pageURL {
+			fmt.Printf("Found feed %s\n", candidates[0].URL)
+		}
+		return candidates[0].URL, nil
+	}
+	fmt.Printf("Found %d feeds at %s:\n", len(candidates), pageURL)
+	for _, candidate := range candidates {
+		fmt.Printf("* %s (%s) %s\n", candidate.Title, candidate.Type, candidate.URL)
+	}
+	return "", fmt.Errorf("run the command again with one of the feed URLs above")
+}
+
+// returns the plain text rendering of a post's full content, or of its description without one
+func postPlainText(item parser.Entry) sql.NullString {
+	body := item.Content
+	if body == "" {
+		body = item.Description
+	}
+	// stored even when empty, NULL marks posts saved before the sanitizer
+	return sql.NullString{String: sanitize.Text(body), Valid: true}
+}
+
+// returns the key a post is deduplicated by within its feed
+// the item's guid/Atom id, or a hash of link and title when the feed has none
+func postGUID(item parser.Entry) string {
+	guid := strings.TrimSpace(item.ID)
+	if guid != "" {
+		return guid
+	}
+	sum := sha256.Sum256([]byte(item.Link + "\n" + item.Title))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// returns a hash of the fields of a post that can be edited by the author
+// without full content it must match the backfill in sql/schema/007_add_revisions_to_posts.sql
+func postContentHash(item parser.Entry) string {
+	hashed := item.Title + "\n" + item.Description
+	if item.Content != "" {
+		hashed += "\n" + item.Content
+	}
+	sum := sha256.Sum256([]byte(hashed))
+	return hex.EncodeToString(sum[:])
+}
+
+// handlerAgg helper function
+// claim the feeds that were fetched the longest ago and scrape them
+// with at most concurrency feeds in flight at once
+// workerID identifies this aggregator process in the lease of every claimed feed
+func scrapeFeeds(ctx context.Context, s state, concurrency int, workerID uuid.UUID) error {
+	// claiming leases the batch and marks it fetched in one statement, so other
+	// aggregator processes skip these feeds until the lease is released or expires
+	now := time.Now()
+	claimParams := database.ClaimFeedsToFetchParams{
+		Time:           sql.NullTime{Time: now, Valid: true},
+		LeaseOwner:     uuid.NullUUID{UUID: workerID, Valid: true},
+		LeaseExpiresAt: sql.NullTime{Time: now.Add(feedLeaseDuration), Valid: true},
+		MaxFeeds:       int32(concurrency),
+	}
+	nextFeeds, err := s.db.ClaimFeedsToFetch(ctx, claimParams)
+	if err != nil {
+		return err
+	}
+	if len(nextFeeds) == 0 {
+		return nil
+	}
+
+	jobs := make(chan database.Feed)
+	errs := make(chan error, len(nextFeeds))
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for feed := range jobs {
+				// a failing feed is backed off instead of stopping the aggregator
+				outcome, scrapeErr := scrapeFeed(ctx, s, feed)
+				recordErr := recordScrapeResult(ctx, s, feed, outcome, scrapeErr)
+				releaseParams := database.ReleaseFeedLeaseParams{
+					ID:         feed.ID,
+					LeaseOwner: uuid.NullUUID{UUID: workerID, Valid: true},
+				}
+				releaseErr := s.db.ReleaseFeedLease(ctx, releaseParams)
+				errs <- errors.Join(recordErr, releaseErr)
+			}
+		}()
+	}
+	for _, feed := range nextFeeds {
+		jobs <- feed
+	}
+	close(jobs)
+	wg.Wait()
+	close(errs)
+
+	scrapeErrs := []error{}
+	for err := range errs {
+		if err != nil {
+			scrapeErrs = append(scrapeErrs, err)
+		}
+	}
+	return errors.Join(scrapeErrs...)
+}
+
+// returns how long to wait before fetching a feed again after consecutive failures
+func feedBackoff(failures int32) time.Duration {
+	backoff := feedBackoffBase
+	for i := int32(1); i < failures; i++ {
+		backoff *= 2
+		if backoff >= feedBackoffMax {
+			return feedBackoffMax
+		}
+	}
+	return backoff
+}
+
+// store the outcome of a scrape on the feed, scheduling a retry with backoff on failure
+// a feed held back by the rate limit of its host is postponed without counting as a failure
+func recordScrapeResult(ctx context.Context, s state, feed database.Feed, outcome scrapeOutcome, scrapeErr error) error {
+	statusCode := outcome.StatusCode
+	lastStatusCode := sql.NullInt32{Int32: int32(statusCode), Valid: statusCode != 0}
+	if scrapeErr == nil {
+		successParams := database.RecordFeedSuccessParams{
+			LastStatusCode:         lastStatusCode,
+			LastSuccessAt:          sql.NullTime{Time: time.Now(), Valid: true},
+			NextFetchAt:            sql.NullTime{Time: outcome.NextFetchAt, Valid: !outcome.NextFetchAt.IsZero()},
+			RefreshIntervalSeconds: sql.NullInt32{Int32: int32(outcome.RefreshInterval / time.Second), Valid: outcome.RefreshInterval > 0},
+			ID:                     feed.ID,
+		}
+		return s.db.RecordFeedSuccess(ctx, successParams)
+	}
+	var rateLimitedErr *fetcher.RateLimitedError
+	if errors.As(scrapeErr, &rateLimitedErr) {
+		// nothing was requested, the feed did not fail
+		nextFetchAt := time.Now().Add(rateLimitedErr.Delay)
+		fmt.Printf("Postponed %s to %s: %v\n", feed.Url.String, nextFetchAt.Format(time.RFC1123), scrapeErr)
+		postponeParams := database.PostponeFeedFetchParams{
+			NextFetchAt: sql.NullTime{Time: nextFetchAt, Valid: true},
+			ID:          feed.ID,
+		}
+		return s.db.PostponeFeedFetch(ctx, postponeParams)
+	}
+	failures := feed.ConsecutiveFailures + 1
+	nextFetchAt := time.Now().Add(feedBackoff(failures))
+	// a 429 or 503 may say when to come back, never retry earlier than that
+	retryAt := time.Now().Add(fetcher.RetryAfter(scrapeErr))
+	if retryAt.After(nextFetchAt) {
+		nextFetchAt = retryAt
+	}
+	fmt.Printf("Error scraping %s (%d consecutive failures, retrying after %s): %v\n",
+		feed.Url.String, failures, nextFetchAt.Format(time.RFC1123), scrapeErr)
+	failureParams := database.RecordFeedFailureParams{
+		LastError:      sql.NullString{String: scrapeErr.Error(), Valid: true},
+		LastErrorKind:  sql.NullString{String: fetcher.ErrorKind(scrapeErr), Valid: true},
+		LastStatusCode: lastStatusCode,
+		NextFetchAt:    sql.NullTime{Time: nextFetchAt, Valid: true},
+		ID:             feed.ID,
+	}
+	err := s.db.RecordFeedFailure(ctx, failureParams)
+	if err != nil {
+		return err
+	}
+
+	// 410 Gone is permanent, anything else gets the configured number of attempts
+	maxFailures := s.config.MaxFeedFailures
+	if maxFailures <= 0 {
+		maxFailures = defaultMaxFeedFailures
+	}
+	if statusCode != http.StatusGone && int(failures) < maxFailures {
+		return nil
+	}
+	fmt.Printf("Disabling %s, run enablefeed %s to fetch it again\n", feed.Url.String, feed.Url.String)
+	disableParams := database.DisableFeedParams{
+		DisabledAt: sql.NullTime{Time: time.Now(), Valid: true},
+		ID:         feed.ID,
+	}
+	return s.db.DisableFeed(ctx, disableParams)
+}
+
+// update the URL of a feed that permanently redirects,
+// the old URL stays as an alias so follow and unfollow still resolve it
+func moveFeed(ctx context.Context, s state, feed database.Feed, newURL string) error {
+	params := database.UpdateFeedURLParams{
+		AliasID:   uuid.New(),
+		UpdatedAt: time.Now(),
+		ID:        feed.ID,
+		Url:       sql.NullString{String: newURL, Valid: true},
+	}
+	err := s.db.UpdateFeedURL(ctx, params)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Feed moved permanently: %s -> %s\n", feed.Url.String, newURL)
+	return nil
+}
+
+// outcome of scraping one feed, StatusCode is set even when the scrape failed
+type scrapeOutcome struct {
+	// HTTP status of the fetch, 0 when no response was received
+	StatusCode int
+	// when to fetch the feed again and the interval it was computed from, zero on failure
+	NextFetchAt     time.Time
+	RefreshInterval time.Duration
+}
+
+// scrape a single feed and save its posts to the database
+func scrapeFeed(ctx context.Context, s state, nextFeed database.Feed) (scrapeOutcome, error) {
+	fetchedAt := time.Now()
+	result, err := s.fetcher.FetchFeed(ctx, nextFeed.Url.String, nextFeed.Etag.String, nextFeed.LastModified.String)
+	if err != nil {
+		return scrapeOutcome{StatusCode: fetcher.StatusCode(err)}, err
+	}
+	outcome := scrapeOutcome{StatusCode: result.StatusCode}
+	if result.PermanentURL != "" && result.PermanentURL != nextFeed.Url.String {
+		err = moveFeed(ctx, s, nextFeed, result.PermanentURL)
+		if err != nil {
+			fmt.Printf("Could not move %s to %s: %v\n", nextFeed.Url.String, result.PermanentURL, err)
+		}
+	}
+	if result.NotModified {
+		fmt.Println("Feed not modified:", nextFeed.Url.String)
+		// the feed's own hints are only known from a full fetch, keep the previous interval
+		hints := scheduler.Hints{MaxAge: result.MaxAge}
+		outcome.RefreshInterval = scheduler.Interval(hints, s.refreshBounds)
+		if nextFeed.RefreshIntervalSeconds.Valid {
+			previous := time.Duration(nextFeed.RefreshIntervalSeconds.Int32) * time.Second
+			if previous > outcome.RefreshInterval {
+				outcome.RefreshInterval = previous
+			}
+		}
+		outcome.NextFetchAt = scheduler.NextFetch(time.Now(), outcome.RefreshInterval, hints)
+		return outcome, nil
+	}
+	feed := result.Feed
+	published, err := savePosts(ctx, s, nextFeed, feed, fetchedAt)
 	if err != nil {
-		return nil, err
+		return outcome, err
 	}
-	client := &http.Client{}
-	req.Header.Add("User-Agent", "Gator")
-	res, err := client.Do(req)
+	// store the validators only once every post is saved, so a failed run is fetched in full again
+	cacheParams := database.UpdateFeedCacheHeadersParams{
+		Etag:         sql.NullString{String: result.ETag, Valid: result.ETag != ""},
+		LastModified: sql.NullString{String: result.LastModified, Valid: result.LastModified != ""},
+		ID:           nextFeed.ID,
+	}
+	err = s.db.UpdateFeedCacheHeaders(ctx, cacheParams)
 	if err != nil {
-		return nil, err
+		return outcome, err
 	}
-	defer res.Body.Close()
-	xmlBytes, err := io.ReadAll(res.Body)
+	pushed, err := subscribeWebsub(ctx, s, nextFeed, feed)
 	if err != nil {
-		return nil, err
+		// polling keeps the feed up to date, the subscription is retried on the next fetch
+		fmt.Printf("Could not subscribe to the WebSub hub of %s: %v\n", nextFeed.Url.String, err)
+	}
+
+	hints := scheduler.Hints{
+		TTL:             feed.Hints.TTL,
+		UpdateInterval:  feed.Hints.UpdateInterval,
+		MaxAge:          result.MaxAge,
+		PostingInterval: scheduler.PostingInterval(published),
+		SkipHours:       feed.Hints.SkipHours,
+		SkipDays:        feed.Hints.SkipDays,
+		Pushed:          pushed,
 	}
-	rssFeed := &RSSFeed{}
-	err = xml.Unmarshal(xmlBytes, rssFeed)
+	outcome.RefreshInterval = scheduler.Interval(hints, s.refreshBounds)
+	outcome.NextFetchAt = scheduler.NextFetch(time.Now(), outcome.RefreshInterval, hints)
+	return outcome, nil
+}
+
+// save the media files attached to a post, updating the ones already saved
+func saveEnclosures(ctx context.Context, s state, postID uuid.UUID, enclosures []parser.Enclosure) error {
+	for _, enclosure := range enclosures {
+		durationSeconds := int32(enclosure.Duration / time.Second)
+		params := database.UpsertPostEnclosureParams{
+			ID:              uuid.New(),
+			CreatedAt:       time.Now(),
+			UpdatedAt:       time.Now(),
+			PostID:          postID,
+			Url:             enclosure.URL,
+			Length:          sql.NullInt64{Int64: enclosure.Length, Valid: enclosure.Length > 0},
+			MimeType:        sql.NullString{String: enclosure.Type, Valid: enclosure.Type != ""},
+			DurationSeconds: sql.NullInt32{Int32: durationSeconds, Valid: durationSeconds > 0},
+			Season:          sql.NullInt32{Int32: int32(enclosure.Season), Valid: enclosure.Season > 0},
+			Episode:         sql.NullInt32{Int32: int32(enclosure.Episode), Valid: enclosure.Episode > 0},
+			ThumbnailUrl:    sql.NullString{String: enclosure.Thumbnail, Valid: enclosure.Thumbnail != ""},
+		}
+		err := s.db.UpsertPostEnclosure(ctx, params)
+		if err != nil {
+			return fmt.Errorf("error saving enclosure %s: %v", enclosure.URL, err)
+		}
+	}
+	return nil
+}
+
+// subscribe to the WebSub hub a feed advertises, reports whether the hub already pushes its updates
+func subscribeWebsub(ctx context.Context, s state, nextFeed database.Feed, feed *parser.Feed) (bool, error) {
+	if s.websub == nil || feed.Hub == "" {
+		return false, nil
+	}
+	// the hub and self links may be relative to the feed URL
+	feedURL, err := url.Parse(nextFeed.Url.String)
 	if err != nil {
-		return nil, err
+		return false, err
+	}
+	hubURL, err := feedURL.Parse(feed.Hub)
+	if err != nil {
+		return false, fmt.Errorf("invalid hub URL %q: %v", feed.Hub, err)
+	}
+	topic := nextFeed.Url.String
+	if feed.Self != "" {
+		selfURL, err := feedURL.Parse(feed.Self)
+		if err == nil {
+			topic = selfURL.String()
+		}
 	}
-	rssFeed.Channel.Title = html.UnescapeString(rssFeed.Channel.Title)
-	rssFeed.Channel.Description = html.EscapeString(rssFeed.Channel.Description)
-	for _, item := range rssFeed.Channel.Item {
-		item.Description = item.Description
-		item.Title = item.Title
 
+	sub, err := s.db.GetWebsubSubscriptionByFeed(ctx, nextFeed.ID)
+	if err != nil && err != sql.ErrNoRows {
+		return false, err
+	}
+	if err == nil && sub.HubUrl == hubURL.String() && sub.TopicUrl == topic {
+		// retries and renewals are sent by renewWebsubSubscriptions
+		return sub.ExpiresAt.Valid && sub.ExpiresAt.Time.After(time.Now()), nil
+	}
+
+	secret, err := websub.NewSecret()
+	if err != nil {
+		return false, err
 	}
-	return rssFeed, nil
+	now := time.Now()
+	params := database.UpsertWebsubSubscriptionParams{
+		ID:          uuid.New(),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		FeedID:      nextFeed.ID,
+		HubUrl:      hubURL.String(),
+		TopicUrl:    topic,
+		Secret:      secret,
+		RequestedAt: now,
+	}
+	sub, err = s.db.UpsertWebsubSubscription(ctx, params)
+	if err != nil {
+		return false, err
+	}
+	fmt.Printf("Subscribing to %s at WebSub hub %s\n", topic, sub.HubUrl)
+	return false, s.websub.Subscribe(ctx, sub.HubUrl, sub.TopicUrl, sub.ID.String(), sub.Secret)
 }
 
 // handlerAgg helper function
-// scrape feeds and save posts to the database
-func scrapeFeeds(ctx context.Context, s state) error {
-	nextFeed, err := s.db.GetNextFeedToFetch(context.Background())
+// send the subscription requests the hubs did not confirm again and renew the leases about to expire
+func renewWebsubSubscriptions(ctx context.Context, s state) error {
+	now := time.Now()
+	renewParams := database.GetWebsubSubscriptionsToRenewParams{
+		RetryBefore: now.Add(-websubRetryInterval),
+		Now:         now,
+	}
+	subs, err := s.db.GetWebsubSubscriptionsToRenew(ctx, renewParams)
 	if err != nil {
 		return err
 	}
-	markFeedParams := database.MarkFeedFetchedParams{
-		Time: sql.NullTime{Time: time.Now(), Valid: true},
-		ID:   nextFeed.ID,
+	for _, sub := range subs {
+		requestedParams := database.MarkWebsubSubscriptionRequestedParams{
+			RequestedAt: now,
+			ID:          sub.ID,
+		}
+		err = s.db.MarkWebsubSubscriptionRequested(ctx, requestedParams)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Renewing WebSub subscription to %s at %s\n", sub.TopicUrl, sub.HubUrl)
+		err = s.websub.Subscribe(ctx, sub.HubUrl, sub.TopicUrl, sub.ID.String(), sub.Secret)
+		if err != nil {
+			fmt.Printf("Could not renew the WebSub subscription to %s: %v\n", sub.TopicUrl, err)
+		}
 	}
-	err = s.db.MarkFeedFetched(context.Background(), markFeedParams)
+	return nil
+}
+
+// serve the WebSub callbacks in the background for as long as agg runs
+func startWebsubListener(s state) error {
+	callbackURL, err := url.Parse(s.config.WebsubCallbackURL)
 	if err != nil {
 		return err
 	}
-	RSSfeed, err := fetchFeed(context.Background(), nextFeed.Url.String)
+	handler := &websub.Handler{
+		Store:       websubStore{s: s},
+		MaxBodySize: s.config.MaxFeedSize,
+		ErrorLog:    log.Printf,
+	}
+	mux := http.NewServeMux()
+	mux.Handle(strings.TrimSuffix(callbackURL.Path, "/")+"/", handler)
+	// listen before returning so a port that is already taken fails agg right away
+	listener, err := net.Listen("tcp", s.config.WebsubListenAddr)
+	if err != nil {
+		return fmt.Errorf("could not listen for WebSub callbacks: %v", err)
+	}
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		err := server.Serve(listener)
+		log.Printf("WebSub listener stopped: %v", err)
+	}()
+	fmt.Printf("Listening for WebSub callbacks on %s, reachable at %s\n", listener.Addr(), s.config.WebsubCallbackURL)
+	return nil
+}
+
+// websubStore gives the WebSub callback handler access to the subscriptions in the database
+type websubStore struct {
+	s state
+}
+
+func (w websubStore) get(ctx context.Context, id string) (database.WebsubSubscription, error) {
+	subID, err := uuid.Parse(id)
+	if err != nil {
+		return database.WebsubSubscription{}, websub.ErrUnknownSubscription
+	}
+	sub, err := w.s.db.GetWebsubSubscription(ctx, subID)
+	if err == sql.ErrNoRows {
+		return sub, websub.ErrUnknownSubscription
+	}
+	return sub, err
+}
+
+func (w websubStore) Subscription(ctx context.Context, id string) (websub.Subscription, error) {
+	sub, err := w.get(ctx, id)
+	if err != nil {
+		return websub.Subscription{}, err
+	}
+	return websub.Subscription{Topic: sub.TopicUrl, Secret: sub.Secret}, nil
+}
+
+func (w websubStore) Verified(ctx context.Context, id string, lease time.Duration) error {
+	sub, err := w.get(ctx, id)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	params := database.VerifyWebsubSubscriptionParams{
+		VerifiedAt: sql.NullTime{Time: now, Valid: true},
+		ExpiresAt:  sql.NullTime{Time: now.Add(lease), Valid: true},
+		ID:         sub.ID,
+	}
+	err = w.s.db.VerifyWebsubSubscription(ctx, params)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("WebSub subscription to %s confirmed until %s\n", sub.TopicUrl, params.ExpiresAt.Time.Format(time.RFC1123))
+	return nil
+}
+
+func (w websubStore) Denied(ctx context.Context, id string, reason string) error {
+	sub, err := w.get(ctx, id)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("WebSub hub %s denied the subscription to %s: %s\n", sub.HubUrl, sub.TopicUrl, reason)
+	return w.s.db.DeleteWebsubSubscription(ctx, sub.ID)
+}
+
+// saves pushed content through the same path as fetched feeds
+func (w websubStore) Deliver(ctx context.Context, id string, contentType string, body []byte) error {
+	sub, err := w.get(ctx, id)
+	if err != nil {
+		return err
+	}
+	feed, err := w.s.db.GetFeedByID(ctx, sub.FeedID)
 	if err != nil {
 		return err
 	}
+	parsed, err := fetcher.ParseFeed(feed.Url.String, contentType, body)
+	if err != nil {
+		// the hub would only send the same content again, so it is dropped
+		fmt.Printf("Dropped pushed content of %s: %v\n", feed.Url.String, err)
+		return nil
+	}
+	fmt.Printf("Received %d pushed items for %s\n", len(parsed.Entries), feed.Url.String)
+	_, err = savePosts(ctx, w.s, feed, parsed, time.Now())
+	return err
+}
 
-	for _, item := range RSSfeed.Channel.Item {
-		createPostsParams := database.CreatePostsParams{}
-		createPostsParams.ID = uuid.New()
-		createPostsParams.CreatedAt = time.Now()
-		createPostsParams.UpdatedAt = time.Now()
+// save the entries of a fetched or pushed feed document as posts of the feed
+// returns the publication dates that could be parsed
+func savePosts(ctx context.Context, s state, nextFeed database.Feed, feed *parser.Feed, fetchedAt time.Time) ([]time.Time, error) {
+	// items whose date could not be parsed are saved with the fetch time
+	unparseableDates := []string{}
+	published := []time.Time{}
+	for _, item := range feed.Entries {
+		upsertPostParams := database.UpsertPostParams{}
+		upsertPostParams.ID = uuid.New()
+		upsertPostParams.CreatedAt = time.Now()
+		upsertPostParams.UpdatedAt = time.Now()
 		if item.Title != "" {
-			createPostsParams.Title = sql.NullString{String: item.Title, Valid: true}
+			upsertPostParams.Title = sql.NullString{String: item.Title, Valid: true}
 		} else {
-			createPostsParams.Title = sql.NullString{Valid: false}
+			upsertPostParams.Title = sql.NullString{Valid: false}
 		}
 
-		createPostsParams.Url = sql.NullString{String: item.Link, Valid: true}
-		createPostsParams.Description = sql.NullString{String: item.Description, Valid: true}
-		pubDate, err := time.Parse(time.RFC1123, item.PubDate)
+		upsertPostParams.Url = sql.NullString{String: item.Link, Valid: true}
+		upsertPostParams.Description = sql.NullString{String: item.Description, Valid: true}
+		pubDate, err := parser.ParseDate(item.PubDate)
 		if err != nil {
-			return err
+			unparseableDates = append(unparseableDates, fmt.Sprintf("%s (%v)", item.Link, err))
+			pubDate = fetchedAt
+		} else {
+			// ParseDate returns UTC, the posts table stores local times like created_at
+			pubDate = pubDate.In(fetchedAt.Location())
+			published = append(published, pubDate)
+		}
+		upsertPostParams.PublishedAt = sql.NullTime{Time: pubDate, Valid: true}
+		upsertPostParams.FeedID = uuid.NullUUID{UUID: nextFeed.ID, Valid: true}
+		upsertPostParams.Guid = postGUID(item)
+		upsertPostParams.ContentHash = postContentHash(item)
+		upsertPostParams.Content = sql.NullString{String: item.Content, Valid: item.Content != ""}
+		upsertPostParams.PlainText = postPlainText(item)
+		// a post saved before guids were stored is keyed by its url, move it to the
+		// guid it is saved under now instead of inserting the entry a second time
+		legacyParams := database.AdoptLegacyPostGUIDParams{
+			Guid:   upsertPostParams.Guid,
+			FeedID: upsertPostParams.FeedID,
+			Url:    upsertPostParams.Url,
 		}
-		createPostsParams.PublishedAt = sql.NullTime{Time: pubDate, Valid: true}
-		createPostsParams.FeedID = uuid.NullUUID{UUID: nextFeed.ID, Valid: true}
-		post, err := s.db.CreatePosts(context.Background(), createPostsParams)
+		err = s.db.AdoptLegacyPostGUID(ctx, legacyParams)
 		if err != nil {
-			// ignore unique violation
-			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
-				fmt.Println("Duplicate URL, ignoring:", item.Link)
-			} else {
-				return fmt.Errorf("error creating post: %v", err)
+			return published, fmt.Errorf("error saving post: %v", err)
+		}
+		post, err := s.db.UpsertPost(ctx, upsertPostParams)
+		if err == sql.ErrNoRows {
+			// the post already exists and its content did not change, its enclosures may have
+			if len(item.Enclosures) > 0 {
+				guidParams := database.GetPostIDByGUIDParams{FeedID: upsertPostParams.FeedID, Guid: upsertPostParams.Guid}
+				postID, err := s.db.GetPostIDByGUID(ctx, guidParams)
+				if err != nil {
+					return published, fmt.Errorf("error saving enclosures: %v", err)
+				}
+				err = saveEnclosures(ctx, s, postID, item.Enclosures)
+				if err != nil {
+					return published, err
+				}
 			}
+			continue
+		}
+		if err != nil {
+			return published, fmt.Errorf("error saving post: %v", err)
+		}
+		err = saveEnclosures(ctx, s, post.ID, item.Enclosures)
+		if err != nil {
+			return published, err
+		}
+		if post.RevisionCount > 0 {
+			fmt.Printf("Updated post (revision %d): %s\n", post.RevisionCount, item.Link)
+			continue
 		}
 		fmt.Printf("%v\n", post)
 	}
+	if len(unparseableDates) > 0 {
+		fmt.Printf("%d items in %s had unparseable dates, used the fetch time:\n", len(unparseableDates), nextFeed.Url.String)
+		for _, item := range unparseableDates {
+			fmt.Printf("  %s\n", item)
+		}
+	}
+	return published, nil
+}
 
-	return nil
+// read the fetch limits from the config, unset limits are left to the fetcher defaults
+func fetchOptionsFromConfig(cfg Config) (fetcher.Options, error) {
+	opts := fetcher.Options{MaxBodySize: cfg.MaxFeedSize, MaxRateLimitWait: feedRateLimitWait}
+	var err error
+	if cfg.FetchConnectTimeout != "" {
+		opts.ConnectTimeout, err = time.ParseDuration(cfg.FetchConnectTimeout)
+		if err != nil {
+			return opts, fmt.Errorf("fetch_connect_timeout: %v", err)
+		}
+	}
+	if cfg.FetchTimeout != "" {
+		opts.Timeout, err = time.ParseDuration(cfg.FetchTimeout)
+		if err != nil {
+			return opts, fmt.Errorf("fetch_timeout: %v", err)
+		}
+	}
+	opts.HostRateLimits = map[string]fetcher.RateLimit{}
+	for domain, limit := range cfg.HostRateLimits {
+		if limit.RequestsPerMinute <= 0 || limit.Burst < 0 {
+			return opts, fmt.Errorf("host_rate_limits: %s: requests_per_minute must be positive and burst not negative", domain)
+		}
+		opts.HostRateLimits[domain] = fetcher.RateLimit{RequestsPerMinute: limit.RequestsPerMinute, Burst: limit.Burst}
+	}
+	if cfg.DefaultHostRateLimit != nil {
+		limit := cfg.DefaultHostRateLimit
+		if limit.RequestsPerMinute < 0 || limit.Burst < 0 {
+			return opts, fmt.Errorf("default_host_rate_limit: requests_per_minute and burst must not be negative")
+		}
+		opts.DefaultRateLimit = fetcher.RateLimit{RequestsPerMinute: limit.RequestsPerMinute, Burst: limit.Burst}
+	}
+	return opts, nil
+}
+
+// read the bounds of the per-feed refresh interval, unset bounds are left to the scheduler defaults
+func refreshBoundsFromConfig(cfg Config) (scheduler.Bounds, error) {
+	bounds := scheduler.Bounds{}
+	var err error
+	if cfg.MinRefreshInterval != "" {
+		bounds.Min, err = time.ParseDuration(cfg.MinRefreshInterval)
+		if err != nil {
+			return bounds, fmt.Errorf("min_refresh_interval: %v", err)
+		}
+	}
+	if cfg.MaxRefreshInterval != "" {
+		bounds.Max, err = time.ParseDuration(cfg.MaxRefreshInterval)
+		if err != nil {
+			return bounds, fmt.Errorf("max_refresh_interval: %v", err)
+		}
+	}
+	return bounds, nil
+}
+
+// returns the directory download saves enclosures to, ~/gator-downloads unless the config sets one
+func downloadDirFromConfig(cfg Config) (string, error) {
+	if cfg.DownloadDir != "" {
+		return cfg.DownloadDir, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, "gator-downloads"), nil
+}
+
+// returns the client subscribing to WebSub hubs, nil when push subscriptions are not configured
+func websubClientFromConfig(cfg Config) (*websub.Client, error) {
+	if cfg.WebsubCallbackURL == "" && cfg.WebsubListenAddr == "" {
+		return nil, nil
+	}
+	if cfg.WebsubCallbackURL == "" || cfg.WebsubListenAddr == "" {
+		return nil, fmt.Errorf("websub_callback_url and websub_listen_addr must be set together")
+	}
+	callbackURL, err := url.Parse(cfg.WebsubCallbackURL)
+	if err != nil {
+		return nil, fmt.Errorf("websub_callback_url: %v", err)
+	}
+	if (callbackURL.Scheme != "http" && callbackURL.Scheme != "https") || callbackURL.Host == "" {
+		return nil, fmt.Errorf("websub_callback_url must be an absolute http(s) URL: %s", cfg.WebsubCallbackURL)
+	}
+	client := &websub.Client{
+		HTTPClient:  &http.Client{Timeout: fetcher.DefaultTimeout},
+		CallbackURL: cfg.WebsubCallbackURL,
+	}
+	return client, nil
 }
 
 func main() {
@@ -453,6 +1331,20 @@ func main() {
 	}
 	dbQueries := database.New(db)
 	state.db = dbQueries
+	//feed fetching limits
+	fetchOptions, err := fetchOptionsFromConfig(cfg)
+	if err != nil {
+		log.Fatalf("Invalid fetch limits in config: %s", err)
+	}
+	state.fetcher = fetcher.NewClient(fetchOptions)
+	state.refreshBounds, err = refreshBoundsFromConfig(cfg)
+	if err != nil {
+		log.Fatalf("Invalid refresh intervals in config: %s", err)
+	}
+	state.websub, err = websubClientFromConfig(cfg)
+	if err != nil {
+		log.Fatalf("Invalid WebSub settings in config: %s", err)
+	}
 
 	//register commands
 	commands.registerHandler("login", handlerLogin)
@@ -462,10 +1354,14 @@ func main() {
 	commands.registerHandler("agg", handlerAgg)
 	commands.registerHandler("addfeed", middlewareLoggedIn(handlerAddFeed))
 	commands.registerHandler("feeds", handlerFeeds)
+	commands.registerHandler("feedhealth", handlerFeedHealth)
+	commands.registerHandler("enablefeed", handlerEnableFeed)
 	commands.registerHandler("follow", middlewareLoggedIn(handlerFollow))
 	commands.registerHandler("following", middlewareLoggedIn(handlerFollowing))
 	commands.registerHandler("unfollow", middlewareLoggedIn(handlerUnfollow))
 	commands.registerHandler("browse", middlewareLoggedIn(handlerBrowse))
+	commands.registerHandler("read", handlerRead)
+	commands.registerHandler("download", handlerDownload)
 	if len(os.Args) < 2 {
 		log.Fatalf("no command given")
 	}
@@ -29,4 +29,5 @@ DELETE FROM feed_follows
 USING feeds
 WHERE @user_id = feed_follows.user_id
 AND feed_follows.feed_id = feeds.id
-AND @feed_url = feeds.url;
\ No newline at end of file
+AND (@feed_url = feeds.url
+OR feeds.id IN (SELECT feed_aliases.feed_id FROM feed_aliases WHERE feed_aliases.url = @feed_url));
\ No newline at end of file
@@ -11,20 +11,83 @@ VALUES (
 RETURNING *;
 
 
+-- name: DisableFeed :exec
+UPDATE feeds SET disabled_at = @disabled_at WHERE id = @id;
+
+-- name: EnableFeed :one
+UPDATE feeds
+SET disabled_at = NULL,
+    consecutive_failures = 0,
+    next_fetch_at = NULL
+WHERE url = @url
+OR id IN (SELECT feed_aliases.feed_id FROM feed_aliases WHERE feed_aliases.url = @url)
+RETURNING *;
+
 -- name: GetFeedByID :one
 SELECT * FROM feeds
 WHERE feeds.id = $1;
 
 -- name: GetFeedByURL :one
 SELECT * FROM feeds
-WHERE feeds.url = $1;
+WHERE feeds.url = $1
+OR feeds.id IN (SELECT feed_aliases.feed_id FROM feed_aliases WHERE feed_aliases.url = $1);
 
 -- name: GetFeeds :many
 SELECT * FROM feeds;
 
--- name: MarkFeedFetched :exec
-UPDATE feeds SET last_fetched_at = @time, updated_at = @time WHERE id = @id;
+-- name: ClaimFeedsToFetch :many
+UPDATE feeds
+SET last_fetched_at = @time,
+    updated_at = @time,
+    lease_owner = @lease_owner,
+    lease_expires_at = @lease_expires_at
+WHERE id IN (
+    SELECT id FROM feeds
+    WHERE (feeds.lease_expires_at IS NULL OR feeds.lease_expires_at < @time)
+    AND (feeds.next_fetch_at IS NULL OR feeds.next_fetch_at <= @time)
+    AND feeds.disabled_at IS NULL
+    ORDER BY feeds.last_fetched_at ASC NULLS FIRST
+    LIMIT @max_feeds
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
 
--- name: GetNextFeedToFetch :one
-SELECT * FROM feeds
-ORDER BY last_fetched_at ASC NULLS FIRST;
\ No newline at end of file
+-- name: RecordFeedFailure :exec
+UPDATE feeds
+SET consecutive_failures = consecutive_failures + 1,
+    last_error = @last_error,
+    last_error_kind = @last_error_kind,
+    last_status_code = @last_status_code,
+    next_fetch_at = @next_fetch_at
+WHERE id = @id;
+
+-- name: RecordFeedSuccess :exec
+UPDATE feeds
+SET consecutive_failures = 0,
+    last_error = NULL,
+    last_error_kind = NULL,
+    last_status_code = @last_status_code,
+    last_success_at = @last_success_at,
+    next_fetch_at = @next_fetch_at,
+    refresh_interval_seconds = @refresh_interval_seconds
+WHERE id = @id;
+
+-- name: PostponeFeedFetch :exec
+UPDATE feeds SET next_fetch_at = @next_fetch_at WHERE id = @id;
+
+-- name: ReleaseFeedLease :exec
+UPDATE feeds SET lease_owner = NULL, lease_expires_at = NULL
+WHERE id = @id AND lease_owner = @lease_owner;
+
+-- name: UpdateFeedURL :exec
+WITH inserted_alias AS (
+    INSERT INTO feed_aliases (id, created_at, feed_id, url)
+    SELECT @alias_id::uuid, @updated_at::timestamp, feeds.id, feeds.url
+    FROM feeds
+    WHERE feeds.id = @id AND feeds.url IS NOT NULL
+    ON CONFLICT (url) DO NOTHING
+)
+UPDATE feeds SET url = @url, updated_at = @updated_at::timestamp WHERE feeds.id = @id;
+
+-- name: UpdateFeedCacheHeaders :exec
+UPDATE feeds SET etag = @etag, last_modified = @last_modified WHERE id = @id;
\ No newline at end of file
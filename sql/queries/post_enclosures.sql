@@ -0,0 +1,28 @@
+-- name: UpsertPostEnclosure :exec
+INSERT INTO post_enclosures(id, created_at, updated_at, post_id, url, length, mime_type, duration_seconds, season, episode, thumbnail_url)
+VALUES(
+    @id,
+    @created_at,
+    @updated_at,
+    @post_id,
+    @url,
+    @length,
+    @mime_type,
+    @duration_seconds,
+    @season,
+    @episode,
+    @thumbnail_url
+)
+ON CONFLICT (post_id, url) DO UPDATE
+SET updated_at = EXCLUDED.updated_at,
+    length = EXCLUDED.length,
+    mime_type = EXCLUDED.mime_type,
+    duration_seconds = EXCLUDED.duration_seconds,
+    season = EXCLUDED.season,
+    episode = EXCLUDED.episode,
+    thumbnail_url = EXCLUDED.thumbnail_url;
+
+-- name: GetEnclosuresForPost :many
+SELECT * FROM post_enclosures
+WHERE post_id = $1
+ORDER BY created_at, url;
\ No newline at end of file
@@ -1,5 +1,5 @@
--- name: CreatePosts :one
-INSERT INTO posts(id, created_at, updated_at, title, url, description, published_at, feed_id)
+-- name: UpsertPost :one
+INSERT INTO posts(id, created_at, updated_at, title, url, description, published_at, feed_id, guid, content_hash, content, plain_text)
 VALUES(
     @id,
     @created_at,
@@ -8,14 +8,59 @@ VALUES(
     @url,
     @description,
     @published_at,
-    @feed_id
+    @feed_id,
+    @guid,
+    @content_hash,
+    @content,
+    @plain_text
 )
+ON CONFLICT (feed_id, guid) DO UPDATE
+SET title = EXCLUDED.title,
+    url = EXCLUDED.url,
+    description = EXCLUDED.description,
+    content = EXCLUDED.content,
+    content_hash = EXCLUDED.content_hash,
+    updated_at = EXCLUDED.updated_at,
+    plain_text = EXCLUDED.plain_text,
+    -- posts saved before the sanitizer have no plain_text, rewriting them once is not a new revision
+    revision_count = posts.revision_count + CASE
+        WHEN posts.plain_text IS NULL THEN 0
+        ELSE 1
+    END
+WHERE posts.content_hash <> EXCLUDED.content_hash
 RETURNING *;
 
 -- name: GetPostsForUser :many
-SELECT posts.title, posts.description, posts.published_at, posts.url, feeds.name FROM posts
+SELECT posts.id, posts.title, posts.description, posts.published_at, posts.url, posts.revision_count, posts.content IS NOT NULL AS has_content, feeds.name FROM posts
 INNER JOIN feeds
 ON feeds.id = feed_id 
 WHERE feeds.user_id = $1
 ORDER BY published_at DESC 
-LIMIT $2;
\ No newline at end of file
+LIMIT $2;
+
+-- name: GetFeedPostStats :many
+SELECT feed_id, COUNT(*) AS post_count, MIN(published_at)::timestamp AS oldest_post_at, MAX(published_at)::timestamp AS newest_post_at
+FROM posts
+WHERE published_at IS NOT NULL
+GROUP BY feed_id;
+
+-- name: GetPost :one
+SELECT * FROM posts
+WHERE id = $1;
+
+-- name: GetPostIDByGUID :one
+SELECT id FROM posts
+WHERE feed_id = $1 AND guid = $2;
+
+-- name: AdoptLegacyPostGUID :exec
+UPDATE posts
+SET guid = @guid
+WHERE feed_id = @feed_id
+    AND url = @url
+    -- posts saved before guids were stored were backfilled with their url as guid
+    AND guid = url
+    AND guid <> @guid
+    AND NOT EXISTS (
+        SELECT 1 FROM posts AS existing
+        WHERE existing.feed_id = @feed_id AND existing.guid = @guid
+    );
\ No newline at end of file
@@ -0,0 +1,57 @@
+-- name: UpsertWebsubSubscription :one
+INSERT INTO websub_subscriptions(id, created_at, updated_at, feed_id, hub_url, topic_url, secret, requested_at)
+VALUES(
+    @id,
+    @created_at,
+    @updated_at,
+    @feed_id,
+    @hub_url,
+    @topic_url,
+    @secret,
+    @requested_at
+)
+ON CONFLICT (feed_id) DO UPDATE
+SET updated_at = EXCLUDED.updated_at,
+    hub_url = EXCLUDED.hub_url,
+    topic_url = EXCLUDED.topic_url,
+    secret = EXCLUDED.secret,
+    requested_at = EXCLUDED.requested_at,
+    verified_at = NULL,
+    expires_at = NULL
+RETURNING *;
+
+-- name: GetWebsubSubscription :one
+SELECT * FROM websub_subscriptions
+WHERE id = $1;
+
+-- name: GetWebsubSubscriptionByFeed :one
+SELECT * FROM websub_subscriptions
+WHERE feed_id = $1;
+
+-- name: GetWebsubSubscriptionsToRenew :many
+SELECT websub_subscriptions.* FROM websub_subscriptions
+INNER JOIN feeds
+ON feeds.id = websub_subscriptions.feed_id
+WHERE feeds.disabled_at IS NULL
+AND (
+    -- never confirmed by the hub, or a renewal was not confirmed
+    ((websub_subscriptions.verified_at IS NULL OR websub_subscriptions.requested_at > websub_subscriptions.verified_at)
+        AND websub_subscriptions.requested_at < @retry_before)
+    -- confirmed and in the last fifth of its lease
+    OR (websub_subscriptions.requested_at <= websub_subscriptions.verified_at
+        AND websub_subscriptions.verified_at + (websub_subscriptions.expires_at - websub_subscriptions.verified_at) * 4 / 5 < @now)
+);
+
+-- name: MarkWebsubSubscriptionRequested :exec
+UPDATE websub_subscriptions
+SET requested_at = @requested_at, updated_at = @requested_at
+WHERE id = @id;
+
+-- name: VerifyWebsubSubscription :exec
+UPDATE websub_subscriptions
+SET verified_at = @verified_at, expires_at = @expires_at, updated_at = @verified_at
+WHERE id = @id;
+
+-- name: DeleteWebsubSubscription :exec
+DELETE FROM websub_subscriptions
+WHERE id = $1;
\ No newline at end of file
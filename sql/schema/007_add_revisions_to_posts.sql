@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE posts
+ADD COLUMN content_hash TEXT NOT NULL DEFAULT '',
+ADD COLUMN revision_count INTEGER NOT NULL DEFAULT 0;
+
+UPDATE posts
+SET content_hash = encode(sha256(convert_to(coalesce(title, '') || E'\n' || coalesce(description, ''), 'UTF8')), 'hex');
+
+-- +goose Down
+ALTER TABLE posts
+DROP COLUMN content_hash,
+DROP COLUMN revision_count;
\ No newline at end of file
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD COLUMN last_success_at TIMESTAMP NULL,
+ADD COLUMN last_status_code INTEGER NULL;
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN last_success_at,
+DROP COLUMN last_status_code;
\ No newline at end of file
@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE feed_aliases(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    feed_id UUID NOT NULL,
+    FOREIGN KEY (feed_id)
+    REFERENCES feeds(id) ON DELETE CASCADE,
+    url TEXT NOT NULL UNIQUE
+);
+
+-- +goose Down
+DROP TABLE feed_aliases;
\ No newline at end of file
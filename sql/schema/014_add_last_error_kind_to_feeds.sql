@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD COLUMN last_error_kind TEXT NULL;
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN last_error_kind;
\ No newline at end of file
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD COLUMN refresh_interval_seconds INTEGER NULL;
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN refresh_interval_seconds;
\ No newline at end of file
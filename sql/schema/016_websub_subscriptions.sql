@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE websub_subscriptions(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    feed_id UUID NOT NULL UNIQUE,
+    FOREIGN KEY (feed_id)
+    REFERENCES feeds(id) ON DELETE CASCADE,
+    hub_url TEXT NOT NULL,
+    topic_url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    requested_at TIMESTAMP NOT NULL,
+    verified_at TIMESTAMP NULL,
+    expires_at TIMESTAMP NULL
+);
+
+-- +goose Down
+DROP TABLE websub_subscriptions;
\ No newline at end of file
@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE post_enclosures(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    post_id UUID NOT NULL,
+    FOREIGN KEY (post_id)
+    REFERENCES posts(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    length BIGINT NULL,
+    mime_type TEXT NULL,
+    duration_seconds INTEGER NULL,
+    season INTEGER NULL,
+    episode INTEGER NULL,
+    thumbnail_url TEXT NULL,
+    UNIQUE(post_id, url)
+);
+
+-- +goose Down
+DROP TABLE post_enclosures;
\ No newline at end of file
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE posts
+ADD COLUMN plain_text TEXT NULL;
+
+-- +goose Down
+ALTER TABLE posts
+DROP COLUMN plain_text;
\ No newline at end of file